@@ -54,6 +54,7 @@ var _ = Describe("Check", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "example.json"
 					},
@@ -61,6 +62,7 @@ var _ = Describe("Check", func() {
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 				snapshotTimestampCurrent.Format(SnapshotTimeFormat),
 			))
@@ -106,12 +108,14 @@ var _ = Describe("Check", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "example.json"
 					}
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 			))
 			Expect(err).NotTo(HaveOccurred())
@@ -146,6 +150,7 @@ var _ = Describe("Check", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "example.json"
 					},
@@ -153,6 +158,7 @@ var _ = Describe("Check", func() {
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 			))
 			Expect(err).NotTo(HaveOccurred())
@@ -186,6 +192,7 @@ var _ = Describe("Check", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"regexp": "example-(.*).json"
 					},
@@ -193,6 +200,7 @@ var _ = Describe("Check", func() {
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 			))
 			Expect(err).NotTo(HaveOccurred())
@@ -240,6 +248,7 @@ var _ = Describe("Check", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"regexp": "example-(.*).json"
 					},
@@ -247,6 +256,7 @@ var _ = Describe("Check", func() {
 				}`,
 					config.StorageAccountName,
 					config.StorageAccountKey,
+					config.BlobEndpoint,
 					container,
 				))
 				Expect(err).NotTo(HaveOccurred())
@@ -278,6 +288,7 @@ var _ = Describe("Check", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"regexp": "example-(.*).json"
 					},
@@ -285,6 +296,7 @@ var _ = Describe("Check", func() {
 				}`,
 					config.StorageAccountName,
 					config.StorageAccountKey,
+					config.BlobEndpoint,
 					container,
 				))
 				Expect(err).NotTo(HaveOccurred())
@@ -50,6 +50,7 @@ var _ = Describe("In", func() {
 						"source": {
 							"storage_account_name": %q,
 							"storage_account_key": %q,
+							"blob_endpoint": %q,
 							"container": %q,
 							"versioned_file": %q
 						},
@@ -60,6 +61,7 @@ var _ = Describe("In", func() {
 					}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 				filename,
 				snapshotTimestamp.Format(SnapshotTimeFormat),
@@ -109,6 +111,7 @@ var _ = Describe("In", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "example.json"
 					},
@@ -116,6 +119,7 @@ var _ = Describe("In", func() {
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 				snapshotTimestamp.Format(SnapshotTimeFormat),
 			))
@@ -142,8 +146,7 @@ var _ = Describe("In", func() {
 			Expect(output.Metadata[1].Name).To(Equal("url"))
 			url, err := url.Parse(output.Metadata[1].Value)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(url.Hostname()).To(Equal(fmt.Sprintf("%s.blob.core.windows.net", config.StorageAccountName)))
-			Expect(url.EscapedPath()).To(Equal(fmt.Sprintf("/%s/example.json", container)))
+			Expect(url.Scheme + "://" + url.Host + url.EscapedPath()).To(Equal(blobURL(container, "example.json")))
 			Expect(len(url.Query()["snapshot"][0])).To(Equal(28)) // azure is sensetive to trailing zero's
 			data, err := ioutil.ReadFile(filepath.Join(tempDir, "example.json"))
 			Expect(err).NotTo(HaveOccurred())
@@ -167,12 +170,14 @@ var _ = Describe("In", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "example.json"
 					}
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 			))
 			Expect(err).NotTo(HaveOccurred())
@@ -198,8 +203,7 @@ var _ = Describe("In", func() {
 			Expect(output.Metadata[1].Name).To(Equal("url"))
 			url, err := url.Parse(output.Metadata[1].Value)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(url.Hostname()).To(Equal(fmt.Sprintf("%s.blob.core.windows.net", config.StorageAccountName)))
-			Expect(url.EscapedPath()).To(Equal(fmt.Sprintf("/%s/example.json", container)))
+			Expect(url.Scheme + "://" + url.Host + url.EscapedPath()).To(Equal(blobURL(container, "example.json")))
 			Expect(len(url.Query()["snapshot"][0])).To(Equal(28)) // azure is sensetive to trailing zero's
 			_, err = os.Stat(filepath.Join(tempDir, "example.json"))
 			Expect(err).NotTo(HaveOccurred())
@@ -226,6 +230,7 @@ var _ = Describe("In", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "sub/example.json"
 					},
@@ -233,6 +238,7 @@ var _ = Describe("In", func() {
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 				snapshotTimestamp.Format(SnapshotTimeFormat),
 			))
@@ -259,8 +265,7 @@ var _ = Describe("In", func() {
 			Expect(output.Metadata[1].Name).To(Equal("url"))
 			url, err := url.Parse(output.Metadata[1].Value)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(url.Hostname()).To(Equal(fmt.Sprintf("%s.blob.core.windows.net", config.StorageAccountName)))
-			Expect(url.EscapedPath()).To(Equal(fmt.Sprintf("/%s/sub/example.json", container)))
+			Expect(url.Scheme + "://" + url.Host + url.EscapedPath()).To(Equal(blobURL(container, "sub/example.json")))
 			Expect(len(url.Query()["snapshot"][0])).To(Equal(28)) // azure is sensitive to trailing zero's
 			_, err = os.Stat(filepath.Join(tempDir, "example.json"))
 			Expect(err).NotTo(HaveOccurred())
@@ -294,6 +299,7 @@ var _ = Describe("In", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "big_file_on_azure"
 					},
@@ -306,6 +312,7 @@ var _ = Describe("In", func() {
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 				snapshotTimestamp.Format(SnapshotTimeFormat),
 			))
@@ -343,6 +350,7 @@ var _ = Describe("In", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "big_file_on_azure"
 					},
@@ -351,6 +359,7 @@ var _ = Describe("In", func() {
 				}`,
 					config.StorageAccountName,
 					config.StorageAccountKey,
+					config.BlobEndpoint,
 					container,
 					snapshotTimestamp.Format(SnapshotTimeFormat),
 				))
@@ -397,6 +406,7 @@ var _ = Describe("In", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"regexp": "example-(.*).json"
 					},
@@ -404,6 +414,7 @@ var _ = Describe("In", func() {
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 			))
 			Expect(err).NotTo(HaveOccurred())
@@ -435,8 +446,7 @@ var _ = Describe("In", func() {
 			Expect(output.Metadata[1].Name).To(Equal("url"))
 			url, err := url.Parse(output.Metadata[1].Value)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(url.Hostname()).To(Equal(fmt.Sprintf("%s.blob.core.windows.net", config.StorageAccountName)))
-			Expect(url.EscapedPath()).To(Equal(fmt.Sprintf("/%s/example-1.2.3.json", container)))
+			Expect(url.Scheme + "://" + url.Host + url.EscapedPath()).To(Equal(blobURL(container, "example-1.2.3.json")))
 			_, err = os.Stat(filepath.Join(tempDir, "example-1.2.3.json"))
 			Expect(err).NotTo(HaveOccurred())
 		})
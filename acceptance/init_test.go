@@ -1,16 +1,17 @@
 package acceptance_test
 
 import (
-	"encoding/base64"
+	"context"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/Azure/azure-storage-blob-go/azblob"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gexec"
@@ -28,6 +29,7 @@ func TestAcceptance(t *testing.T) {
 type Config struct {
 	StorageAccountName string
 	StorageAccountKey  string
+	BlobEndpoint       string
 }
 
 var (
@@ -60,6 +62,7 @@ func loadConfig() Config {
 	config := Config{
 		StorageAccountName: os.Getenv("TEST_STORAGE_ACCOUNT_NAME"),
 		StorageAccountKey:  os.Getenv("TEST_STORAGE_ACCOUNT_KEY"),
+		BlobEndpoint:       os.Getenv("TEST_BLOB_ENDPOINT"),
 	}
 
 	if config.StorageAccountName == "" {
@@ -73,157 +76,106 @@ func loadConfig() Config {
 	return config
 }
 
-func createContainer(container string) {
-	client, err := storage.NewBasicClient(os.Getenv("TEST_STORAGE_ACCOUNT_NAME"), os.Getenv("TEST_STORAGE_ACCOUNT_KEY"))
-	Expect(err).NotTo(HaveOccurred())
+// blobEndpoint returns TEST_BLOB_ENDPOINT, or the public cloud endpoint of
+// the test storage account.
+func blobEndpoint() string {
+	if config.BlobEndpoint != "" {
+		return strings.TrimSuffix(config.BlobEndpoint, "/")
+	}
 
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(container)
-	err = cnt.Create(&storage.CreateContainerOptions{
-		Access: storage.ContainerAccessTypePrivate,
-	})
-	Expect(err).NotTo(HaveOccurred())
+	return fmt.Sprintf("https://%s.blob.core.windows.net", config.StorageAccountName)
 }
 
-func deleteContainer(container string) {
-	client, err := storage.NewBasicClient(os.Getenv("TEST_STORAGE_ACCOUNT_NAME"), os.Getenv("TEST_STORAGE_ACCOUNT_KEY"))
-	Expect(err).NotTo(HaveOccurred())
-
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(container)
-	err = cnt.Delete(&storage.DeleteContainerOptions{})
-	Expect(err).NotTo(HaveOccurred())
+func blobURL(container, blobName string) string {
+	return fmt.Sprintf("%s/%s/%s", blobEndpoint(), container, blobName)
 }
 
-func createBlobWithSnapshot(container, blobName string) *time.Time {
-	client, err := storage.NewBasicClient(os.Getenv("TEST_STORAGE_ACCOUNT_NAME"), os.Getenv("TEST_STORAGE_ACCOUNT_KEY"))
+// containerURL addresses container on blobEndpoint, so that the fixtures are
+// created wherever the resource under test looks for them.
+func containerURL(container string) azblob.ContainerURL {
+	credential, err := azblob.NewSharedKeyCredential(config.StorageAccountName, config.StorageAccountKey)
 	Expect(err).NotTo(HaveOccurred())
 
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(container)
-	blob := cnt.GetBlobReference(blobName)
-	err = blob.CreateBlockBlob(&storage.PutBlobOptions{})
+	endpoint, err := url.Parse(blobEndpoint())
 	Expect(err).NotTo(HaveOccurred())
 
-	timestamp, err := blob.CreateSnapshot(&storage.SnapshotOptions{})
-	Expect(err).NotTo(HaveOccurred())
-
-	return timestamp
+	pipeline := azblob.NewPipeline(credential, azblob.PipelineOptions{})
+	return azblob.NewServiceURL(*endpoint, pipeline).NewContainerURL(container)
 }
 
-func createBlobWithSnapshotAndData(container, blobName, data string) *time.Time {
-	client, err := storage.NewBasicClient(os.Getenv("TEST_STORAGE_ACCOUNT_NAME"), os.Getenv("TEST_STORAGE_ACCOUNT_KEY"))
+func createContainer(container string) {
+	_, err := containerURL(container).Create(context.Background(), azblob.Metadata{}, azblob.PublicAccessNone)
 	Expect(err).NotTo(HaveOccurred())
+}
 
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(container)
-	blob := cnt.GetBlobReference(blobName)
-	err = blob.CreateBlockBlob(&storage.PutBlobOptions{})
+func deleteContainer(container string) {
+	_, err := containerURL(container).Delete(context.Background(), azblob.ContainerAccessConditions{})
 	Expect(err).NotTo(HaveOccurred())
+}
 
-	blockID := base64.StdEncoding.EncodeToString([]byte("BlockID{0000001}"))
-	err = blob.PutBlock(blockID, []byte(data), &storage.PutBlockOptions{})
-
-	err = blob.PutBlockList([]storage.Block{
-		{
-			blockID,
-			storage.BlockStatusUncommitted,
-		},
-	}, &storage.PutBlockListOptions{})
-	Expect(err).NotTo(HaveOccurred())
+func createBlobWithSnapshot(container, blobName string) *time.Time {
+	return createBlobWithSnapshotAndData(container, blobName, "")
+}
 
-	timestamp, err := blob.CreateSnapshot(&storage.SnapshotOptions{})
+func createBlobWithSnapshotAndData(container, blobName, data string) *time.Time {
+	blob := containerURL(container).NewBlockBlobURL(blobName)
+	_, err := blob.Upload(context.Background(), strings.NewReader(data), azblob.BlobHTTPHeaders{}, azblob.Metadata{},
+		azblob.BlobAccessConditions{}, azblob.DefaultAccessTier, nil, azblob.ClientProvidedKeyOptions{})
 	Expect(err).NotTo(HaveOccurred())
 
-	return timestamp
+	return createSnapshot(blob.BlobURL)
 }
 
 func createBlob(container, blobName string) {
-	client, err := storage.NewBasicClient(os.Getenv("TEST_STORAGE_ACCOUNT_NAME"), os.Getenv("TEST_STORAGE_ACCOUNT_KEY"))
-	Expect(err).NotTo(HaveOccurred())
-
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(container)
-	blob := cnt.GetBlobReference(blobName)
-	err = blob.CreateBlockBlob(&storage.PutBlobOptions{})
+	blob := containerURL(container).NewBlockBlobURL(blobName)
+	_, err := blob.Upload(context.Background(), strings.NewReader(""), azblob.BlobHTTPHeaders{}, azblob.Metadata{},
+		azblob.BlobAccessConditions{}, azblob.DefaultAccessTier, nil, azblob.ClientProvidedKeyOptions{})
 	Expect(err).NotTo(HaveOccurred())
 }
 
 func copyBlob(container, blobName, sourceUrl string) {
-	client, err := storage.NewBasicClient(os.Getenv("TEST_STORAGE_ACCOUNT_NAME"), os.Getenv("TEST_STORAGE_ACCOUNT_KEY"))
+	source, err := url.Parse(sourceUrl)
 	Expect(err).NotTo(HaveOccurred())
 
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(container)
-	blob := cnt.GetBlobReference(blobName)
+	blob := containerURL(container).NewBlobURL(blobName)
 	go func() {
-		blob.Copy(sourceUrl, &storage.CopyOptions{})
+		blob.StartCopyFromURL(context.Background(), *source, azblob.Metadata{}, azblob.ModifiedAccessConditions{},
+			azblob.BlobAccessConditions{}, azblob.DefaultAccessTier, nil)
 	}()
 }
 
 func uploadBlobWithSnapshot(container, blobName, filename string) *time.Time {
-	client, err := storage.NewBasicClient(os.Getenv("TEST_STORAGE_ACCOUNT_NAME"), os.Getenv("TEST_STORAGE_ACCOUNT_KEY"))
-	Expect(err).NotTo(HaveOccurred())
-
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(container)
-	blob := cnt.GetBlobReference(blobName)
-
-	err = blob.CreateBlockBlob(&storage.PutBlobOptions{})
-	Expect(err).NotTo(HaveOccurred())
+	blob := containerURL(container).NewBlockBlobURL(blobName)
 
 	file, err := os.Open(filename)
 	Expect(err).NotTo(HaveOccurred())
 	defer file.Close()
 
-	var chunkSize = 4000000 // 4Mb
-	buffer := make([]byte, chunkSize)
-	blocks := []storage.Block{}
-	i := 0
-	for {
-		bytesRead, err := file.Read(buffer)
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-
-			Expect(err).NotTo(HaveOccurred())
-		}
-
-		chunk := buffer[:bytesRead]
-		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("BlockID{%07d}", i)))
-		err = blob.PutBlock(blockID, chunk, &storage.PutBlockOptions{})
-		Expect(err).NotTo(HaveOccurred())
-
-		blocks = append(blocks, storage.Block{
-			blockID,
-			storage.BlockStatusUncommitted,
-		})
-
-		i++
-	}
+	_, err = azblob.UploadFileToBlockBlob(context.Background(), file, blob, azblob.UploadToBlockBlobOptions{
+		BlockSize: 4000000, // 4Mb
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	return createSnapshot(blob.BlobURL)
+}
 
-	err = blob.PutBlockList(blocks, &storage.PutBlockListOptions{})
+func createSnapshot(blob azblob.BlobURL) *time.Time {
+	response, err := blob.CreateSnapshot(context.Background(), azblob.Metadata{}, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
 	Expect(err).NotTo(HaveOccurred())
 
-	timestamp, err := blob.CreateSnapshot(&storage.SnapshotOptions{})
+	timestamp, err := time.Parse(SnapshotTimeFormat, response.Snapshot())
 	Expect(err).NotTo(HaveOccurred())
 
-	return timestamp
+	return &timestamp
 }
 
 func downloadBlobWithSnapshot(container, blobName string, snapshot time.Time) []byte {
-	client, err := storage.NewBasicClient(os.Getenv("TEST_STORAGE_ACCOUNT_NAME"), os.Getenv("TEST_STORAGE_ACCOUNT_KEY"))
-	Expect(err).NotTo(HaveOccurred())
-
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(container)
-	blob := cnt.GetBlobReference(blobName)
+	blob := containerURL(container).NewBlobURL(blobName).WithSnapshot(snapshot.Format(SnapshotTimeFormat))
 
-	blobReader, err := blob.Get(&storage.GetBlobOptions{
-		Snapshot: &snapshot,
-	})
+	response, err := blob.Download(context.Background(), 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
 	Expect(err).NotTo(HaveOccurred())
+
+	blobReader := response.Body(azblob.RetryReaderOptions{})
 	defer blobReader.Close()
 
 	data, err := ioutil.ReadAll(blobReader)
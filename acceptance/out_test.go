@@ -59,12 +59,14 @@ var _ = Describe("Out", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "example.json"
 					}
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 			))
 			Expect(err).NotTo(HaveOccurred())
@@ -111,12 +113,14 @@ var _ = Describe("Out", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"versioned_file": "big_file"
 					}
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 			))
 			Expect(err).NotTo(HaveOccurred())
@@ -160,12 +164,14 @@ var _ = Describe("Out", func() {
 					"source": {
 						"storage_account_name": %q,
 						"storage_account_key": %q,
+						"blob_endpoint": %q,
 						"container": %q,
 						"regexp": "some-blob-sub-dir/example-(.*).txt"
 					}
 				}`,
 				config.StorageAccountName,
 				config.StorageAccountKey,
+				config.BlobEndpoint,
 				container,
 			))
 			Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,21 @@
+package api
+
+import "github.com/pivotal-cf/azure-blobstore-resource/azure"
+
+// BlobStateFilter excludes blobs that cannot be read, or are being written,
+// from the versions reported by check. The zero value excludes nothing.
+type BlobStateFilter struct {
+	ExcludeArchived    bool
+	ExcludeRehydrating bool
+	ExcludeLeased      bool
+}
+
+func (f BlobStateFilter) enabled() bool {
+	return f.ExcludeArchived || f.ExcludeRehydrating || f.ExcludeLeased
+}
+
+func (f BlobStateFilter) excludes(state azure.BlobState) bool {
+	return (f.ExcludeArchived && state.Archived()) ||
+		(f.ExcludeRehydrating && state.Rehydrating()) ||
+		(f.ExcludeLeased && state.Leased())
+}
@@ -1,37 +1,68 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
+	"regexp/syntax"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
-	"github.com/cppforlife/go-semi-semantic/version"
 )
 
 type Version struct {
-	Snapshot *time.Time `json:"snapshot,omitempty"`
-	Path     *string    `json:"path,omitempty"`
-	Version  *string    `json:"version,omitempty"`
-
-	comparableVersion version.Version
+	Snapshot     *time.Time `json:"snapshot,omitempty"`
+	Path         *string    `json:"path,omitempty"`
+	Version      *string    `json:"version,omitempty"`
+	VersionID    *string    `json:"version_id,omitempty"`
+	ETag         *string    `json:"etag,omitempty"`
+	LastModified *time.Time `json:"last_modified,omitempty"`
+
+	comparableVersion schemeVersion
+	orderTime         time.Time
 }
 
+// ErrBlobNotFound is returned, wrapped, when the versioned_file blob does not
+// exist.
+var ErrBlobNotFound = errors.New("failed to find blob")
+
 type Check struct {
-	azureClient azureClient
+	azureClient     azureClient
+	stateFilter     BlobStateFilter
+	initialSnapshot time.Time
 }
 
 func NewCheck(azureClient azureClient) Check {
 	return Check{azureClient: azureClient}
 }
 
+// WithBlobStateFilter returns a copy of the check that skips the blobs
+// excluded by filter.
+func (c Check) WithBlobStateFilter(filter BlobStateFilter) Check {
+	c.stateFilter = filter
+	return c
+}
+
+// WithInitialSnapshot returns a copy of the check that never reports snapshots
+// or version ids of a versioned file older than initialSnapshot.
+func (c Check) WithInitialSnapshot(initialSnapshot time.Time) Check {
+	c.initialSnapshot = initialSnapshot
+	return c
+}
+
 func (c Check) VersionsSince(filename string, snapshot time.Time) ([]Version, error) {
+	if snapshot.Before(c.initialSnapshot) {
+		snapshot = c.initialSnapshot
+	}
+
 	blobs := []storage.Blob{}
+	excluded := map[string]bool{}
 	marker := ""
 
 	for {
-		blobListResponse, err := c.azureClient.ListBlobs(storage.ListBlobsParameters{
+		blobListResponse, excludedBlobs, err := c.listBlobs(storage.ListBlobsParameters{
 			Prefix: filename,
 			Include: &storage.IncludeBlobDataset{
 				Snapshots: true,
@@ -48,6 +79,10 @@ func (c Check) VersionsSince(filename string, snapshot time.Time) ([]Version, er
 			blobs = append(blobs, blob)
 		}
 
+		for key := range excludedBlobs {
+			excluded[key] = true
+		}
+
 		marker = blobListResponse.NextMarker
 		if marker == "" {
 			break
@@ -62,6 +97,11 @@ func (c Check) VersionsSince(filename string, snapshot time.Time) ([]Version, er
 		}
 
 		if blob.Name == filename {
+			if excluded[blobKey(blob.Name, blob.Snapshot)] {
+				found = true
+				continue // skip snapshots which cannot be read
+			}
+
 			if blob.Snapshot.After(snapshot) || blob.Snapshot.Equal(snapshot) {
 				newerVersions = append(newerVersions, Version{
 					Snapshot: timePtr(blob.Snapshot),
@@ -72,7 +112,7 @@ func (c Check) VersionsSince(filename string, snapshot time.Time) ([]Version, er
 	}
 
 	if !found {
-		return []Version{}, fmt.Errorf("failed to find blob: %s", filename)
+		return []Version{}, fmt.Errorf("%w: %s", ErrBlobNotFound, filename)
 	}
 
 	sort.Slice(newerVersions, func(i, j int) bool {
@@ -82,16 +122,64 @@ func (c Check) VersionsSince(filename string, snapshot time.Time) ([]Version, er
 	return newerVersions, nil
 }
 
-func (c Check) VersionsSinceRegexp(expr, currentVersion string) ([]Version, error) {
-	blobs := []storage.Blob{}
+// VersionsSinceVersionID returns the blob versions of filename from
+// versionID onwards, for storage accounts with blob versioning enabled.
+func (c Check) VersionsSinceVersionID(filename, versionID string) ([]Version, error) {
+	if initialVersionID := FormatVersionID(c.initialSnapshot); versionID < initialVersionID {
+		versionID = initialVersionID
+	}
+
+	blobVersions, err := c.azureClient.ListBlobVersions(filename)
+	if err != nil {
+		return []Version{}, err
+	}
+
+	var newerVersions []Version
+	var found bool
+	for _, blobVersion := range blobVersions {
+		if blobVersion.Name != filename {
+			continue
+		}
+		found = true
+
+		if blobVersion.CopyStatus != "" && blobVersion.CopyStatus != "success" {
+			continue // skip versions which are still being copied
+		}
+
+		if c.stateFilter.excludes(blobVersion.State) {
+			continue // skip versions which cannot be read
+		}
+
+		// Version ids are timestamps of a fixed width, so they sort lexically.
+		if blobVersion.VersionID >= versionID {
+			newerVersions = append(newerVersions, Version{
+				VersionID: stringPtr(blobVersion.VersionID),
+			})
+		}
+	}
+
+	if !found {
+		return []Version{}, fmt.Errorf("%w versions: %s", ErrBlobNotFound, filename)
+	}
+
+	sort.Slice(newerVersions, func(i, j int) bool {
+		return *newerVersions[i].VersionID < *newerVersions[j].VersionID
+	})
+
+	return newerVersions, nil
+}
+
+// LatestVersionBy returns the current version of filename identified by its
+// ETag or last modified time, for blobs that are overwritten in place rather
+// than snapshotted.
+func (c Check) LatestVersionBy(filename string, versionBy VersionBy) ([]Version, error) {
 	marker := ""
 
-	var hasRan bool
 	for {
-		blobListResponse, err := c.azureClient.ListBlobs(storage.ListBlobsParameters{
+		blobListResponse, excluded, err := c.listBlobs(storage.ListBlobsParameters{
+			Prefix: filename,
 			Include: &storage.IncludeBlobDataset{
-				Snapshots: true,
-				Copy:      true,
+				Copy: true,
 			},
 			Marker: marker,
 		})
@@ -101,73 +189,453 @@ func (c Check) VersionsSinceRegexp(expr, currentVersion string) ([]Version, erro
 		}
 
 		for _, blob := range blobListResponse.Blobs {
-			blobs = append(blobs, blob)
+			if blob.Name != filename {
+				continue
+			}
+
+			if blob.Properties.CopyStatus != "" && blob.Properties.CopyStatus != "success" {
+				return []Version{}, nil // the blob is still being copied, keep the last version
+			}
+
+			if excluded[blobKey(blob.Name, blob.Snapshot)] {
+				return []Version{}, nil // the blob cannot be read, keep the last version
+			}
+
+			switch versionBy {
+			case VersionByETag:
+				return []Version{{ETag: stringPtr(blob.Properties.Etag)}}, nil
+			case VersionByLastModified:
+				return []Version{{LastModified: timePtr(time.Time(blob.Properties.LastModified).UTC())}}, nil
+			default:
+				return []Version{}, fmt.Errorf("unsupported version_by: %s", versionBy)
+			}
 		}
 
 		marker = blobListResponse.NextMarker
-		if marker == "" || (hasRan && len(blobListResponse.Blobs) == 0) {
+		if marker == "" {
 			break
 		}
-
-		hasRan = true
 	}
 
+	return []Version{}, fmt.Errorf("%w: %s", ErrBlobNotFound, filename)
+}
+
+func (c Check) VersionsSinceRegexp(expr, currentVersion string, options RegexpOptions) ([]Version, error) {
 	matcher, err := regexp.Compile(expr)
 	if err != nil {
 		return []Version{}, err
 	}
 
-	curVersion, err := version.NewVersionFromString(currentVersion)
+	blobs, err := c.listRegexpBlobs(expr, storage.IncludeBlobDataset{Copy: true})
 	if err != nil {
-		// ignored, if currentVersion could not be converted to a version we will
-		// assume every version is newer
+		return []Version{}, err
 	}
 
-	var newerVersions []Version
+	var candidates []versionedBlob
 	for _, blob := range blobs {
 		if blob.Properties.CopyStatus != "" && blob.Properties.CopyStatus != "success" {
 			continue // skip blobs which are still being copied
 		}
 
 		var match string
+		if options.OrderBy == OrderByLastModified {
+			if !matcher.MatchString(blob.Name) {
+				continue // no match
+			}
+		} else {
+			var ok bool
+			match, ok = RegexpVersion(matcher, blob.Name)
+			if !ok {
+				continue // no match
+			}
+		}
+
+		candidates = append(candidates, versionedBlob{
+			path:         blob.Name,
+			match:        match,
+			lastModified: time.Time(blob.Properties.LastModified),
+		})
+	}
+
+	newerVersions, err := sinceVersion(candidates, currentVersion, options)
+	if err != nil {
+		return []Version{}, err
+	}
+
+	if len(newerVersions) == 0 {
+		return []Version{}, fmt.Errorf("no matching blob found for regexp: %s", expr)
+	}
+
+	return newerVersions, nil
+}
 
-		matches := matcher.FindStringSubmatch(blob.Name)
-		if len(matches) < 2 {
+// VersionsSinceMetadata returns the versions of the blobs matching expr,
+// taking the version from the user metadata named metadataKey rather than
+// from the blob name. Blobs without the metadata are skipped.
+func (c Check) VersionsSinceMetadata(expr, metadataKey, currentVersion string, options RegexpOptions) ([]Version, error) {
+	matcher, err := regexp.Compile(expr)
+	if err != nil {
+		return []Version{}, err
+	}
+
+	blobs, err := c.listRegexpBlobs(expr, storage.IncludeBlobDataset{Copy: true, Metadata: true})
+	if err != nil {
+		return []Version{}, err
+	}
+
+	// Metadata names are case-insensitive and listed in lower case.
+	metadataKey = strings.TrimPrefix(strings.ToLower(metadataKey), "x-ms-meta-")
+
+	var candidates []versionedBlob
+	for _, blob := range blobs {
+		if blob.Properties.CopyStatus != "" && blob.Properties.CopyStatus != "success" {
+			continue // skip blobs which are still being copied
+		}
+
+		if !matcher.MatchString(blob.Name) {
 			continue // no match
 		}
 
-		index, found := FindSubexpression(matcher.SubexpNames(), "version")
-		if found {
-			match = matches[index]
+		match, ok := blob.Metadata[metadataKey]
+		if !ok || match == "" {
+			continue // not versioned
+		}
+
+		candidates = append(candidates, versionedBlob{
+			path:         blob.Name,
+			match:        match,
+			lastModified: time.Time(blob.Properties.LastModified),
+		})
+	}
+
+	newerVersions, err := sinceVersion(candidates, currentVersion, options)
+	if err != nil {
+		return []Version{}, err
+	}
+
+	if len(newerVersions) == 0 {
+		return []Version{}, fmt.Errorf("no matching blob found with metadata %s for regexp: %s", metadataKey, expr)
+	}
+
+	return newerVersions, nil
+}
+
+// listRegexpBlobs lists the blobs that may match expr, leaving out those
+// excluded by their state.
+func (c Check) listRegexpBlobs(expr string, include storage.IncludeBlobDataset) ([]storage.Blob, error) {
+	blobs := []storage.Blob{}
+	marker := ""
+
+	var hasRan bool
+	for {
+		blobListResponse, excluded, err := c.listBlobs(storage.ListBlobsParameters{
+			Prefix:  listPrefix(expr),
+			Include: &include,
+			Marker:  marker,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, blob := range blobListResponse.Blobs {
+			if excluded[blobKey(blob.Name, blob.Snapshot)] {
+				continue // skip blobs which cannot be read
+			}
+
+			blobs = append(blobs, blob)
+		}
+
+		marker = blobListResponse.NextMarker
+		if marker == "" || (hasRan && len(blobListResponse.Blobs) == 0) {
+			break
+		}
+
+		hasRan = true
+	}
+
+	return blobs, nil
+}
+
+// VersionsSinceTagFilter returns the versions of the blobs whose index tags
+// match tagFilter. The version is taken from the tag named versionTag when
+// given, otherwise from the blob name matched by expr.
+func (c Check) VersionsSinceTagFilter(tagFilter, expr, versionTag, currentVersion string, options RegexpOptions) ([]Version, error) {
+	if expr == "" && versionTag == "" {
+		return []Version{}, errors.New("regexp or version_tag must be provided with tag_filter")
+	}
+
+	if options.OrderBy == OrderByLastModified {
+		return []Version{}, errors.New("order_by last_modified is not supported with tag_filter")
+	}
+
+	var matcher *regexp.Regexp
+	if expr != "" {
+		var err error
+		matcher, err = regexp.Compile(expr)
+		if err != nil {
+			return []Version{}, err
+		}
+	}
+
+	blobs, err := c.azureClient.FindBlobsByTags(tagFilter)
+	if err != nil {
+		return []Version{}, err
+	}
+
+	var candidates []versionedBlob
+	for _, blob := range blobs {
+		var match string
+		var ok bool
+
+		if versionTag == "" {
+			match, ok = RegexpVersion(matcher, blob.Name)
+			if !ok {
+				continue // no match
+			}
 		} else {
-			match = matches[1]
+			if matcher != nil && !matcher.MatchString(blob.Name) {
+				continue // no match
+			}
+
+			// Only the tags referenced by the filter are returned with the
+			// blob, so the version tag may need to be fetched.
+			match, ok = blob.Tags[versionTag]
+			if !ok {
+				tags, err := c.azureClient.GetBlobTags(blob.Name)
+				if err != nil {
+					return []Version{}, err
+				}
+
+				match, ok = tags[versionTag]
+				if !ok {
+					continue // not versioned
+				}
+			}
+		}
+
+		// Found blobs carry no state, so it is only fetched for candidates.
+		if c.stateFilter.enabled() {
+			state, err := c.azureClient.GetBlobState(blob.Name)
+			if err != nil {
+				return []Version{}, err
+			}
+
+			if c.stateFilter.excludes(state) {
+				continue // skip blobs which cannot be read
+			}
+		}
+
+		candidates = append(candidates, versionedBlob{path: blob.Name, match: match})
+	}
+
+	newerVersions, err := sinceVersion(candidates, currentVersion, options)
+	if err != nil {
+		return []Version{}, err
+	}
+
+	if len(newerVersions) == 0 {
+		return []Version{}, fmt.Errorf("no matching blob found for tag_filter: %s", tagFilter)
+	}
+
+	return newerVersions, nil
+}
+
+// listBlobs lists a page of blobs. When the state filter excludes any state
+// it also returns the keys of the listed blobs it excludes, taken from the
+// same listing.
+func (c Check) listBlobs(params storage.ListBlobsParameters) (storage.BlobListResponse, map[string]bool, error) {
+	if !c.stateFilter.enabled() {
+		blobListResponse, err := c.azureClient.ListBlobs(params)
+		return blobListResponse, nil, err
+	}
+
+	blobListResponse, states, err := c.azureClient.ListBlobsWithStates(params)
+	if err != nil {
+		return storage.BlobListResponse{}, nil, err
+	}
+
+	excluded := map[string]bool{}
+	for i, blob := range blobListResponse.Blobs {
+		if i < len(states) && c.stateFilter.excludes(states[i]) {
+			excluded[blobKey(blob.Name, blob.Snapshot)] = true
+		}
+	}
+
+	return blobListResponse, excluded, nil
+}
+
+func blobKey(name string, snapshot time.Time) string {
+	return name + "?" + FormatVersionID(snapshot)
+}
+
+// NewestVersions returns the last max of versions, which are ordered oldest
+// first. A max of zero returns every version.
+func NewestVersions(versions []Version, max int) []Version {
+	if max <= 0 || len(versions) <= max {
+		return versions
+	}
+
+	return versions[len(versions)-max:]
+}
+
+// versionedBlob is a blob together with the unparsed version it carries.
+type versionedBlob struct {
+	path         string
+	match        string
+	lastModified time.Time
+}
+
+// sinceVersion returns the versions of the blobs allowed by options that are
+// not older than currentVersion nor the initial version, oldest first.
+func sinceVersion(blobs []versionedBlob, currentVersion string, options RegexpOptions) ([]Version, error) {
+	switch options.OrderBy {
+	case OrderByLastModified:
+		return sinceTime(blobs, currentVersion, options.InitialVersion, time.RFC3339Nano, false, func(blob versionedBlob) (string, time.Time, error) {
+			return LastModifiedVersion(blob.lastModified), blob.lastModified, nil
+		})
+	case OrderByCaptureTime:
+		return sinceTime(blobs, currentVersion, options.InitialVersion, options.CaptureTimeLayout, options.SkipUnparsable, func(blob versionedBlob) (string, time.Time, error) {
+			t, err := time.Parse(options.CaptureTimeLayout, blob.match)
+			return blob.match, t, err
+		})
+	}
+
+	curVersion, err := options.comparableVersion(currentVersion)
+	if err != nil {
+		// ignored, if currentVersion could not be converted to a version we will
+		// assume every version is newer
+	}
+
+	var initialVersion schemeVersion
+	if options.InitialVersion != "" {
+		initialVersion, err = options.comparableVersion(options.InitialVersion)
+		if err != nil {
+			return []Version{}, fmt.Errorf("failed to parse initial version %q: %s", options.InitialVersion, err)
+		}
+	}
+
+	var newerVersions []Version
+	for _, blob := range blobs {
+		ver, err := options.Scheme.parse(blob.match)
+		if err != nil {
+			if options.SkipUnparsable {
+				continue
+			}
+			return []Version{}, err
 		}
 
-		ver, err := version.NewVersionFromString(match)
+		comparableVer, err := options.comparableVersion(blob.match)
 		if err != nil {
+			if options.SkipUnparsable {
+				continue
+			}
 			return []Version{}, err
 		}
 
-		if currentVersion == "" || ver.Compare(curVersion) >= 0 {
+		if !options.allows(comparableVer) {
+			continue
+		}
+
+		if initialVersion != nil && comparableVer.compare(initialVersion) < 0 {
+			continue
+		}
+
+		if currentVersion == "" || curVersion == nil || comparableVer.compare(curVersion) >= 0 {
 			newerVersions = append(newerVersions, Version{
-				Path:              stringPtr(blob.Name),
-				Version:           stringPtr(ver.AsString()),
-				comparableVersion: ver,
+				Path:              stringPtr(blob.path),
+				Version:           stringPtr(ver.String()),
+				comparableVersion: comparableVer,
 			})
 		}
 	}
 
-	if len(newerVersions) == 0 {
-		return []Version{}, fmt.Errorf("no matching blob found for regexp: %s", expr)
+	sort.Slice(newerVersions, func(i, j int) bool {
+		return newerVersions[i].comparableVersion.compare(newerVersions[j].comparableVersion) < 0
+	})
+
+	return newerVersions, nil
+}
+
+// sinceTime returns the versions of the blobs that are not older than
+// currentVersion nor initialVersion, both parsed with layout, ordered by the
+// time blobTime returns. Blobs with the same time are ordered by path.
+func sinceTime(blobs []versionedBlob, currentVersion, initialVersion, layout string, skipUnparsable bool, blobTime func(versionedBlob) (string, time.Time, error)) ([]Version, error) {
+	curTime, err := time.Parse(layout, currentVersion)
+	if err != nil {
+		// ignored, if currentVersion could not be parsed we will assume every
+		// version is newer
+		currentVersion = ""
+	}
+
+	var initialTime time.Time
+	if initialVersion != "" {
+		initialTime, err = time.Parse(layout, initialVersion)
+		if err != nil {
+			return []Version{}, fmt.Errorf("failed to parse initial version %q: %s", initialVersion, err)
+		}
+	}
+
+	var newerVersions []Version
+	for _, blob := range blobs {
+		ver, t, err := blobTime(blob)
+		if err != nil {
+			if skipUnparsable {
+				continue
+			}
+			return []Version{}, err
+		}
+
+		if initialVersion != "" && t.Before(initialTime) {
+			continue
+		}
+
+		if currentVersion == "" || !t.Before(curTime) {
+			newerVersions = append(newerVersions, Version{
+				Path:      stringPtr(blob.path),
+				Version:   stringPtr(ver),
+				orderTime: t,
+			})
+		}
 	}
 
 	sort.Slice(newerVersions, func(i, j int) bool {
-		return newerVersions[i].comparableVersion.Compare(newerVersions[j].comparableVersion) < 0
+		if newerVersions[i].orderTime.Equal(newerVersions[j].orderTime) {
+			return *newerVersions[i].Path < *newerVersions[j].Path
+		}
+		return newerVersions[i].orderTime.Before(newerVersions[j].orderTime)
 	})
 
 	return newerVersions, nil
 }
 
+// listPrefix returns the literal text every blob name matching expr starts
+// with, so that only those blobs need to be listed. Only a regexp anchored
+// with ^ has such a prefix, since an unanchored regexp may match anywhere in
+// the name.
+func listPrefix(expr string) string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	re = re.Simplify()
+
+	if re.Op != syntax.OpConcat || len(re.Sub) == 0 || re.Sub[0].Op != syntax.OpBeginText {
+		return ""
+	}
+
+	var prefix strings.Builder
+	for _, sub := range re.Sub[1:] {
+		if sub.Op != syntax.OpLiteral || sub.Flags&syntax.FoldCase != 0 {
+			break
+		}
+
+		prefix.WriteString(string(sub.Rune))
+	}
+
+	return prefix.String()
+}
+
 func stringPtr(str string) *string {
 	return &str
 }
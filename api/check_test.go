@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/pivotal-cf/azure-blobstore-resource/api"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
 )
 
 var _ = Describe("Check", func() {
@@ -161,15 +162,503 @@ var _ = Describe("Check", func() {
 				})
 			})
 
+			Context("given an initial snapshot", func() {
+				BeforeEach(func() {
+					check = check.WithInitialSnapshot(expectedSnapshotNew)
+				})
+
+				It("starts from the initial snapshot without a current snapshot", func() {
+					latestVersions, err := check.VersionsSince("example.json", time.Time{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(latestVersions).To(HaveLen(2))
+					Expect(latestVersions[0].Snapshot).To(Equal(&expectedSnapshotNew))
+					Expect(latestVersions[1].Snapshot).To(Equal(&expectedSnapshotNewer))
+				})
+
+				It("never reports snapshots older than the initial snapshot", func() {
+					latestVersions, err := check.VersionsSince("example.json", expectedSnapshotCurrent)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(latestVersions).To(HaveLen(2))
+					Expect(latestVersions[0].Snapshot).To(Equal(&expectedSnapshotNew))
+				})
+
+				It("starts from a current snapshot newer than the initial snapshot", func() {
+					latestVersions, err := check.VersionsSince("example.json", expectedSnapshotNewer)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(latestVersions).To(HaveLen(1))
+					Expect(latestVersions[0].Snapshot).To(Equal(&expectedSnapshotNewer))
+				})
+			})
+
 			Context("when the file is not found", func() {
 				It("returns an error", func() {
 					_, err := check.VersionsSince("non-existant.json", time.Now())
 					Expect(err).To(MatchError("failed to find blob: non-existant.json"))
+					Expect(errors.Is(err, api.ErrBlobNotFound)).To(BeTrue())
 				})
 			})
 		})
 	})
 
+	Describe("VersionsSinceVersionID", func() {
+		BeforeEach(func() {
+			azureClient.ListBlobVersionsReturns([]azure.BlobVersion{
+				{Name: "example.json", VersionID: "2017-01-03T01:01:01.0000000Z"},
+				{Name: "example.json", VersionID: "2017-01-01T01:01:01.0000000Z"},
+				{Name: "example.json", VersionID: "2017-01-04T01:01:01.0000000Z", IsCurrentVersion: true},
+				{Name: "example.json", VersionID: "2017-01-02T01:01:01.0000000Z"},
+				{Name: "example.json", VersionID: "2017-01-05T01:01:01.0000000Z", CopyStatus: "pending"},
+				{Name: "example.json.sha256", VersionID: "2017-01-04T01:01:01.0000000Z"},
+			}, nil)
+		})
+
+		It("returns the versions from the current to the latest version id", func() {
+			latestVersions, err := check.VersionsSinceVersionID("example.json", "2017-01-02T01:01:01.0000000Z")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.ListBlobVersionsCallCount()).To(Equal(1))
+			Expect(azureClient.ListBlobVersionsArgsForCall(0)).To(Equal("example.json"))
+
+			Expect(latestVersions).To(Equal([]api.Version{
+				{VersionID: stringPtr("2017-01-02T01:01:01.0000000Z")},
+				{VersionID: stringPtr("2017-01-03T01:01:01.0000000Z")},
+				{VersionID: stringPtr("2017-01-04T01:01:01.0000000Z")},
+			}))
+		})
+
+		It("returns every version without a current version id", func() {
+			latestVersions, err := check.VersionsSinceVersionID("example.json", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(latestVersions).To(HaveLen(4))
+		})
+
+		Context("given an initial snapshot", func() {
+			BeforeEach(func() {
+				check = check.WithInitialSnapshot(time.Date(2017, time.January, 03, 01, 01, 01, 0, time.UTC))
+			})
+
+			It("starts from the initial version id without a current version id", func() {
+				latestVersions, err := check.VersionsSinceVersionID("example.json", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(latestVersions).To(Equal([]api.Version{
+					{VersionID: stringPtr("2017-01-03T01:01:01.0000000Z")},
+					{VersionID: stringPtr("2017-01-04T01:01:01.0000000Z")},
+				}))
+			})
+
+			It("never reports version ids older than the initial version id", func() {
+				latestVersions, err := check.VersionsSinceVersionID("example.json", "2017-01-01T01:01:01.0000000Z")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(latestVersions).To(HaveLen(2))
+				Expect(latestVersions[0].VersionID).To(Equal(stringPtr("2017-01-03T01:01:01.0000000Z")))
+			})
+		})
+
+		It("returns an error when the blob has no versions", func() {
+			_, err := check.VersionsSinceVersionID("missing.json", "")
+			Expect(err).To(MatchError("failed to find blob versions: missing.json"))
+			Expect(errors.Is(err, api.ErrBlobNotFound)).To(BeTrue())
+		})
+
+		It("returns an error when listing the versions fails", func() {
+			azureClient.ListBlobVersionsReturns(nil, errors.New("failed to list blob versions"))
+
+			_, err := check.VersionsSinceVersionID("example.json", "")
+			Expect(err).To(MatchError("failed to list blob versions"))
+		})
+	})
+
+	Describe("WithBlobStateFilter", func() {
+		var (
+			oldSnapshot time.Time
+			newSnapshot time.Time
+		)
+
+		BeforeEach(func() {
+			oldSnapshot = time.Date(2017, time.January, 01, 01, 01, 01, 0, time.UTC)
+			newSnapshot = time.Date(2017, time.January, 02, 01, 01, 01, 0, time.UTC)
+
+			blobListResponse := storage.BlobListResponse{
+				Blobs: []storage.Blob{
+					storage.Blob{Name: "example-1.0.0.json", Snapshot: oldSnapshot},
+					storage.Blob{Name: "example-1.0.0.json", Snapshot: newSnapshot},
+					storage.Blob{Name: "example-1.0.0.json"},
+					storage.Blob{Name: "example-1.1.0.json"},
+					storage.Blob{Name: "example-1.2.0.json"},
+					storage.Blob{Name: "example-1.3.0.json"},
+				},
+			}
+			azureClient.ListBlobsReturns(blobListResponse, nil)
+			azureClient.ListBlobsWithStatesReturns(blobListResponse, []azure.BlobState{
+				{AccessTier: "Hot"},
+				{AccessTier: "Archive"},
+				{AccessTier: "Archive"},
+				{AccessTier: "Archive", ArchiveStatus: "rehydrate-pending-to-hot"},
+				{AccessTier: "Hot", LeaseState: "leased"},
+				{AccessTier: "Hot", LeaseState: "available"},
+			}, nil)
+
+			check = check.WithBlobStateFilter(api.BlobStateFilter{
+				ExcludeArchived:    true,
+				ExcludeRehydrating: true,
+				ExcludeLeased:      true,
+			})
+		})
+
+		It("does not list blob states without a filter", func() {
+			check = api.NewCheck(azureClient)
+
+			latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.ListBlobsWithStatesCallCount()).To(Equal(0))
+			Expect(latestVersions).To(HaveLen(6))
+		})
+
+		It("skips regexp blobs that are archived, rehydrating or leased in a single listing", func() {
+			latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.ListBlobsWithStatesCallCount()).To(Equal(1))
+			Expect(azureClient.ListBlobsCallCount()).To(Equal(0))
+
+			Expect(latestVersions).To(HaveLen(2))
+			Expect(latestVersions[0].Path).To(Equal(stringPtr("example-1.0.0.json")))
+			Expect(latestVersions[1].Path).To(Equal(stringPtr("example-1.3.0.json")))
+		})
+
+		It("only skips the states it excludes", func() {
+			check = check.WithBlobStateFilter(api.BlobStateFilter{ExcludeLeased: true})
+
+			latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.1.0", api.RegexpOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(latestVersions).To(HaveLen(2))
+			Expect(latestVersions[0].Path).To(Equal(stringPtr("example-1.1.0.json")))
+			Expect(latestVersions[1].Path).To(Equal(stringPtr("example-1.3.0.json")))
+		})
+
+		It("skips rehydrating blobs as archived", func() {
+			check = check.WithBlobStateFilter(api.BlobStateFilter{ExcludeArchived: true})
+
+			latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.1.0", api.RegexpOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(latestVersions).To(HaveLen(2))
+			Expect(latestVersions[0].Path).To(Equal(stringPtr("example-1.2.0.json")))
+			Expect(latestVersions[1].Path).To(Equal(stringPtr("example-1.3.0.json")))
+		})
+
+		It("skips archived snapshots of a versioned file", func() {
+			latestVersions, err := check.VersionsSince("example-1.0.0.json", time.Time{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.ListBlobsWithStatesCallCount()).To(Equal(1))
+			params := azureClient.ListBlobsWithStatesArgsForCall(0)
+			Expect(params.Prefix).To(Equal("example-1.0.0.json"))
+			Expect(params.Include.Snapshots).To(BeTrue())
+
+			Expect(latestVersions).To(HaveLen(1))
+			Expect(*latestVersions[0].Snapshot).To(Equal(oldSnapshot))
+		})
+
+		It("keeps the last version while a versioned file is leased", func() {
+			latestVersions, err := check.LatestVersionBy("example-1.2.0.json", api.VersionByETag)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(latestVersions).To(BeEmpty())
+		})
+
+		It("skips blob versions that are archived", func() {
+			azureClient.ListBlobVersionsReturns([]azure.BlobVersion{
+				{Name: "example.json", VersionID: "2017-01-01T01:01:01.0000000Z"},
+				{Name: "example.json", VersionID: "2017-01-02T01:01:01.0000000Z", State: azure.BlobState{AccessTier: "Archive"}},
+			}, nil)
+
+			latestVersions, err := check.VersionsSinceVersionID("example.json", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(latestVersions).To(HaveLen(1))
+			Expect(latestVersions[0].VersionID).To(Equal(stringPtr("2017-01-01T01:01:01.0000000Z")))
+		})
+
+		It("looks up the state of the blobs found by a tag filter", func() {
+			azureClient.FindBlobsByTagsReturns([]azure.TaggedBlob{
+				{Name: "releases/product-1.2.0.tgz", Tags: map[string]string{"release": "1.2.0"}},
+				{Name: "releases/product-1.3.0.tgz", Tags: map[string]string{"release": "1.3.0"}},
+				{Name: "notes/product-1.3.0.txt", Tags: map[string]string{"release": "1.3.0"}},
+			}, nil)
+			azureClient.GetBlobStateStub = func(blobName string) (azure.BlobState, error) {
+				if blobName == "releases/product-1.3.0.tgz" {
+					return azure.BlobState{AccessTier: "Archive"}, nil
+				}
+				return azure.BlobState{AccessTier: "Hot"}, nil
+			}
+
+			latestVersions, err := check.VersionsSinceTagFilter("release > '1'", `releases/`, "release", "", api.RegexpOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.ListBlobsCallCount()).To(Equal(0))
+			Expect(azureClient.ListBlobsWithStatesCallCount()).To(Equal(0))
+			Expect(azureClient.GetBlobStateCallCount()).To(Equal(2))
+			Expect(azureClient.GetBlobStateArgsForCall(0)).To(Equal("releases/product-1.2.0.tgz"))
+			Expect(azureClient.GetBlobStateArgsForCall(1)).To(Equal("releases/product-1.3.0.tgz"))
+
+			Expect(latestVersions).To(HaveLen(1))
+			Expect(latestVersions[0].Path).To(Equal(stringPtr("releases/product-1.2.0.tgz")))
+		})
+
+		It("returns an error when listing blobs fails", func() {
+			azureClient.ListBlobsWithStatesReturns(storage.BlobListResponse{}, nil, errors.New("failed to list blobs"))
+
+			_, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{})
+			Expect(err).To(MatchError("failed to list blobs"))
+		})
+
+		It("returns an error when the state of a found blob cannot be fetched", func() {
+			azureClient.FindBlobsByTagsReturns([]azure.TaggedBlob{
+				{Name: "releases/product-1.2.0.tgz", Tags: map[string]string{"release": "1.2.0"}},
+			}, nil)
+			azureClient.GetBlobStateReturns(azure.BlobState{}, errors.New("failed to get blob properties"))
+
+			_, err := check.VersionsSinceTagFilter("release > '1'", "", "release", "", api.RegexpOptions{})
+			Expect(err).To(MatchError("failed to get blob properties"))
+		})
+	})
+
+	Describe("NewestVersions", func() {
+		var versions []api.Version
+
+		BeforeEach(func() {
+			versions = []api.Version{
+				{Version: stringPtr("1.0.0")},
+				{Version: stringPtr("1.1.0")},
+				{Version: stringPtr("1.2.0")},
+			}
+		})
+
+		It("returns the newest versions", func() {
+			Expect(api.NewestVersions(versions, 2)).To(Equal([]api.Version{
+				{Version: stringPtr("1.1.0")},
+				{Version: stringPtr("1.2.0")},
+			}))
+		})
+
+		It("returns every version when there are fewer", func() {
+			Expect(api.NewestVersions(versions, 5)).To(HaveLen(3))
+		})
+
+		It("returns every version without a maximum", func() {
+			Expect(api.NewestVersions(versions, 0)).To(HaveLen(3))
+		})
+	})
+
+	Describe("VersionsSinceTagFilter", func() {
+		BeforeEach(func() {
+			azureClient.FindBlobsByTagsReturns([]azure.TaggedBlob{
+				{Name: "releases/product-1.2.0.tgz", Tags: map[string]string{"env": "prod", "release": "1.2.0"}},
+				{Name: "releases/product-1.10.0.tgz", Tags: map[string]string{"env": "prod"}},
+				{Name: "releases/product-1.3.0.tgz", Tags: map[string]string{"env": "prod", "release": "1.3.0"}},
+				{Name: "notes/product-1.3.0.txt", Tags: map[string]string{"env": "prod", "release": "1.3.0"}},
+			}, nil)
+
+			azureClient.GetBlobTagsReturns(map[string]string{"env": "prod", "release": "1.10.0"}, nil)
+		})
+
+		It("returns the versions captured by the regexp from the found blobs", func() {
+			latestVersions, err := check.VersionsSinceTagFilter("env = 'prod'", `releases/product-(.*)\.tgz`, "", "1.3.0", api.RegexpOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.FindBlobsByTagsCallCount()).To(Equal(1))
+			Expect(azureClient.FindBlobsByTagsArgsForCall(0)).To(Equal("env = 'prod'"))
+			Expect(azureClient.ListBlobsCallCount()).To(Equal(0))
+			Expect(azureClient.GetBlobTagsCallCount()).To(Equal(0))
+
+			Expect(latestVersions).To(HaveLen(2))
+			Expect(latestVersions[0].Path).To(Equal(stringPtr("releases/product-1.3.0.tgz")))
+			Expect(latestVersions[0].Version).To(Equal(stringPtr("1.3.0")))
+			Expect(latestVersions[1].Path).To(Equal(stringPtr("releases/product-1.10.0.tgz")))
+			Expect(latestVersions[1].Version).To(Equal(stringPtr("1.10.0")))
+		})
+
+		Context("when a version tag is provided", func() {
+			It("returns the versions from the tag, fetching tags missing from the results", func() {
+				latestVersions, err := check.VersionsSinceTagFilter("env = 'prod'", `releases/`, "release", "", api.RegexpOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(azureClient.GetBlobTagsCallCount()).To(Equal(1))
+				Expect(azureClient.GetBlobTagsArgsForCall(0)).To(Equal("releases/product-1.10.0.tgz"))
+
+				Expect(latestVersions).To(HaveLen(3))
+				Expect(latestVersions[0].Path).To(Equal(stringPtr("releases/product-1.2.0.tgz")))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("1.2.0")))
+				Expect(latestVersions[1].Path).To(Equal(stringPtr("releases/product-1.3.0.tgz")))
+				Expect(latestVersions[2].Path).To(Equal(stringPtr("releases/product-1.10.0.tgz")))
+				Expect(latestVersions[2].Version).To(Equal(stringPtr("1.10.0")))
+			})
+
+			It("uses every found blob without a regexp", func() {
+				latestVersions, err := check.VersionsSinceTagFilter("env = 'prod'", "", "release", "1.3.0", api.RegexpOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(latestVersions).To(HaveLen(3))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("1.3.0")))
+				Expect(latestVersions[1].Version).To(Equal(stringPtr("1.3.0")))
+				Expect(latestVersions[2].Version).To(Equal(stringPtr("1.10.0")))
+			})
+		})
+
+		It("returns an error without a regexp or version tag", func() {
+			_, err := check.VersionsSinceTagFilter("env = 'prod'", "", "", "", api.RegexpOptions{})
+			Expect(err).To(MatchError("regexp or version_tag must be provided with tag_filter"))
+		})
+
+		It("returns an error when no blob is found", func() {
+			azureClient.FindBlobsByTagsReturns(nil, nil)
+
+			_, err := check.VersionsSinceTagFilter("env = 'prod'", "", "release", "", api.RegexpOptions{})
+			Expect(err).To(MatchError("no matching blob found for tag_filter: env = 'prod'"))
+		})
+
+		It("returns an error when finding blobs fails", func() {
+			azureClient.FindBlobsByTagsReturns(nil, errors.New("failed to find blobs"))
+
+			_, err := check.VersionsSinceTagFilter("env = 'prod'", "", "release", "", api.RegexpOptions{})
+			Expect(err).To(MatchError("failed to find blobs"))
+		})
+	})
+
+	Describe("LatestVersionBy", func() {
+		var lastModified time.Time
+
+		BeforeEach(func() {
+			lastModified = time.Date(2017, time.January, 02, 01, 01, 01, 0, time.UTC)
+
+			azureClient.ListBlobsReturns(storage.BlobListResponse{
+				Blobs: []storage.Blob{
+					storage.Blob{
+						Name: "example.json",
+						Properties: storage.BlobProperties{
+							Etag:         "0x8D4A1B2C3D4E5F6",
+							LastModified: storage.TimeRFC1123(lastModified),
+						},
+					},
+					storage.Blob{
+						Name: "example.json.sha256",
+						Properties: storage.BlobProperties{
+							Etag: "0x8D4A1B2C3D4E5F7",
+						},
+					},
+				},
+			}, nil)
+		})
+
+		It("returns the etag of the blob", func() {
+			latestVersions, err := check.LatestVersionBy("example.json", api.VersionByETag)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.ListBlobsCallCount()).To(Equal(1))
+			Expect(azureClient.ListBlobsArgsForCall(0)).To(Equal(storage.ListBlobsParameters{
+				Prefix: "example.json",
+				Include: &storage.IncludeBlobDataset{
+					Copy: true,
+				},
+			}))
+
+			Expect(latestVersions).To(Equal([]api.Version{
+				{ETag: stringPtr("0x8D4A1B2C3D4E5F6")},
+			}))
+		})
+
+		It("returns the last modified time of the blob", func() {
+			latestVersions, err := check.LatestVersionBy("example.json", api.VersionByLastModified)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(latestVersions).To(Equal([]api.Version{
+				{LastModified: &lastModified},
+			}))
+		})
+
+		It("returns no versions while the blob is being copied", func() {
+			azureClient.ListBlobsReturns(storage.BlobListResponse{
+				Blobs: []storage.Blob{
+					storage.Blob{
+						Name: "example.json",
+						Properties: storage.BlobProperties{
+							Etag:       "0x8D4A1B2C3D4E5F6",
+							CopyStatus: "pending",
+						},
+					},
+				},
+			}, nil)
+
+			latestVersions, err := check.LatestVersionBy("example.json", api.VersionByETag)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(latestVersions).To(BeEmpty())
+		})
+
+		It("returns an error when the blob does not exist", func() {
+			_, err := check.LatestVersionBy("missing.json", api.VersionByETag)
+			Expect(err).To(MatchError("failed to find blob: missing.json"))
+			Expect(errors.Is(err, api.ErrBlobNotFound)).To(BeTrue())
+		})
+	})
+
+	Describe("VersionsSinceMetadata", func() {
+		BeforeEach(func() {
+			azureClient.ListBlobsReturnsOnCall(0, storage.BlobListResponse{
+				Blobs: []storage.Blob{
+					storage.Blob{
+						Name:     "builds/3f2a9c.tgz",
+						Metadata: storage.BlobMetadata{"version": "1.10.0"},
+					},
+					storage.Blob{
+						Name:     "builds/8b1d4e.tgz",
+						Metadata: storage.BlobMetadata{"version": "1.2.0"},
+					},
+					storage.Blob{
+						Name: "builds/c0ffee.tgz",
+					},
+					storage.Blob{
+						Name:     "builds/d00d00.tgz",
+						Metadata: storage.BlobMetadata{"version": "1.3.0"},
+						Properties: storage.BlobProperties{
+							CopyStatus: "pending",
+						},
+					},
+					storage.Blob{
+						Name:     "builds/index.json",
+						Metadata: storage.BlobMetadata{"version": "2.0.0"},
+					},
+				},
+			}, nil)
+		})
+
+		It("returns the versions from the metadata of the blobs matching the regex pattern", func() {
+			latestVersions, err := check.VersionsSinceMetadata(`^builds/\w+\.tgz`, "x-ms-meta-Version", "1.2.0", api.RegexpOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.ListBlobsCallCount()).To(Equal(1))
+			Expect(azureClient.ListBlobsArgsForCall(0)).To(Equal(storage.ListBlobsParameters{
+				Prefix: "builds/",
+				Include: &storage.IncludeBlobDataset{
+					Metadata: true,
+					Copy:     true,
+				},
+			}))
+
+			Expect(latestVersions).To(HaveLen(2))
+			Expect(latestVersions[0].Path).To(Equal(stringPtr("builds/8b1d4e.tgz")))
+			Expect(latestVersions[0].Version).To(Equal(stringPtr("1.2.0")))
+			Expect(latestVersions[1].Path).To(Equal(stringPtr("builds/3f2a9c.tgz")))
+			Expect(latestVersions[1].Version).To(Equal(stringPtr("1.10.0")))
+		})
+
+		It("returns an error when no blob has the metadata", func() {
+			_, err := check.VersionsSinceMetadata(`builds/\w+\.tgz`, "release", "", api.RegexpOptions{})
+			Expect(err).To(MatchError(`no matching blob found with metadata release for regexp: builds/\w+\.tgz`))
+		})
+	})
+
 	Describe("VersionsSinceRegexp", func() {
 		Context("given a regex pattern with semver blobs", func() {
 			BeforeEach(func() {
@@ -198,13 +687,13 @@ var _ = Describe("Check", func() {
 			})
 
 			It("returns all the blobs matching the regex pattern newer than given version", func() {
-				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.2.0")
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.2.0", api.RegexpOptions{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(azureClient.ListBlobsCallCount()).To(Equal(1))
 				Expect(azureClient.ListBlobsArgsForCall(0)).To(Equal(storage.ListBlobsParameters{
 					Include: &storage.IncludeBlobDataset{
-						Snapshots:        true,
+						Snapshots:        false,
 						Metadata:         false,
 						UncommittedBlobs: false,
 						Copy:             true,
@@ -219,6 +708,304 @@ var _ = Describe("Check", func() {
 				Expect(latestVersions[2].Path).To(Equal(stringPtr("example-2.0.0.json")))
 				Expect(latestVersions[2].Version).To(Equal(stringPtr("2.0.0")))
 			})
+
+			Context("when a version constraint is provided", func() {
+				It("returns only the versions satisfying the constraint", func() {
+					constraint, err := api.ParseVersionConstraint(">=1.0, <2.0", api.VersionSchemeSemiSemantic)
+					Expect(err).NotTo(HaveOccurred())
+
+					latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{Constraint: constraint})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(latestVersions).To(HaveLen(3))
+					Expect(latestVersions[0].Version).To(Equal(stringPtr("1.0.0")))
+					Expect(latestVersions[1].Version).To(Equal(stringPtr("1.2.0")))
+					Expect(latestVersions[2].Version).To(Equal(stringPtr("1.2.3")))
+				})
+
+				It("returns an error when no version satisfies the constraint", func() {
+					constraint, err := api.ParseVersionConstraint(">=3.0", api.VersionSchemeSemiSemantic)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{Constraint: constraint})
+					Expect(err).To(MatchError("no matching blob found for regexp: example-(.*).json"))
+				})
+			})
+		})
+
+		Context("given a regex pattern with prerelease blobs", func() {
+			BeforeEach(func() {
+				azureClient.ListBlobsReturnsOnCall(0, storage.BlobListResponse{
+					Blobs: []storage.Blob{
+						storage.Blob{
+							Name: "example-1.2.0.json",
+						},
+						storage.Blob{
+							Name: "example-1.2.0-rc.1.json",
+						},
+						storage.Blob{
+							Name: "example-1.1.0.json",
+						},
+						storage.Blob{
+							Name: "example-1.2.0+build.7.json",
+						},
+					},
+				}, nil)
+			})
+
+			It("orders prereleases before their release", func() {
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{
+					Prereleases: api.PrereleasesInclude,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(latestVersions).To(HaveLen(4))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("1.1.0")))
+				Expect(latestVersions[1].Version).To(Equal(stringPtr("1.2.0-rc.1")))
+				Expect(latestVersions[2].Version).To(Equal(stringPtr("1.2.0")))
+				Expect(latestVersions[3].Version).To(Equal(stringPtr("1.2.0+build.7")))
+			})
+
+			It("excludes prereleases", func() {
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{
+					Prereleases: api.PrereleasesExclude,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(latestVersions).To(HaveLen(3))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("1.1.0")))
+				Expect(latestVersions[1].Version).To(Equal(stringPtr("1.2.0")))
+				Expect(latestVersions[2].Version).To(Equal(stringPtr("1.2.0+build.7")))
+			})
+
+			It("includes only prereleases", func() {
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{
+					Prereleases: api.PrereleasesOnly,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(latestVersions).To(HaveLen(1))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("1.2.0-rc.1")))
+			})
+
+			Context("when prerelease suffixes are provided", func() {
+				It("treats versions with the suffixes as prereleases", func() {
+					latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{
+						Prereleases:        api.PrereleasesInclude,
+						PrereleaseSuffixes: []string{"+build."},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(latestVersions).To(HaveLen(4))
+					Expect(latestVersions[0].Version).To(Equal(stringPtr("1.1.0")))
+					Expect(latestVersions[1].Version).To(Equal(stringPtr("1.2.0+build.7")))
+					Expect(latestVersions[2].Version).To(Equal(stringPtr("1.2.0-rc.1")))
+					Expect(latestVersions[3].Version).To(Equal(stringPtr("1.2.0")))
+				})
+
+				It("excludes versions with the suffixes", func() {
+					latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.1.0", api.RegexpOptions{
+						Prereleases:        api.PrereleasesExclude,
+						PrereleaseSuffixes: []string{"+build."},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(latestVersions).To(HaveLen(2))
+					Expect(latestVersions[0].Version).To(Equal(stringPtr("1.1.0")))
+					Expect(latestVersions[1].Version).To(Equal(stringPtr("1.2.0")))
+				})
+			})
+		})
+
+		Context("given an initial version", func() {
+			BeforeEach(func() {
+				azureClient.ListBlobsReturns(storage.BlobListResponse{
+					Blobs: []storage.Blob{
+						storage.Blob{Name: "example-1.0.0.json"},
+						storage.Blob{Name: "example-1.2.0.json"},
+						storage.Blob{Name: "example-2.0.0.json"},
+					},
+				}, nil)
+			})
+
+			It("starts from the initial version without a current version", func() {
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{InitialVersion: "1.1.0"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(latestVersions).To(HaveLen(2))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("1.2.0")))
+				Expect(latestVersions[1].Version).To(Equal(stringPtr("2.0.0")))
+			})
+
+			It("never reports versions older than the initial version", func() {
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.0.0", api.RegexpOptions{InitialVersion: "2.0.0"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(latestVersions).To(HaveLen(1))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("2.0.0")))
+			})
+
+			It("returns an error for an initial version that cannot be parsed", func() {
+				_, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{InitialVersion: "#"})
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse initial version "#"`)))
+			})
+		})
+
+		Context("given a regex pattern with compound version groups", func() {
+			BeforeEach(func() {
+				azureClient.ListBlobsReturnsOnCall(0, storage.BlobListResponse{
+					Blobs: []storage.Blob{
+						storage.Blob{Name: "app_3.1_b452.tgz"},
+						storage.Blob{Name: "app_3.1_b1000.tgz"},
+						storage.Blob{Name: "app_3.10_b1.tgz"},
+						storage.Blob{Name: "app_3.2_b99.tgz"},
+					},
+				}, nil)
+			})
+
+			It("orders the blobs by the combined groups", func() {
+				latestVersions, err := check.VersionsSinceRegexp(`app_(?P<major>\d+)\.(?P<minor>\d+)_b(?P<build>\d+)\.tgz`, "3.1.500", api.RegexpOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(latestVersions).To(HaveLen(3))
+				Expect(latestVersions[0].Path).To(Equal(stringPtr("app_3.1_b1000.tgz")))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("3.1.1000")))
+				Expect(latestVersions[1].Path).To(Equal(stringPtr("app_3.2_b99.tgz")))
+				Expect(latestVersions[2].Path).To(Equal(stringPtr("app_3.10_b1.tgz")))
+			})
+		})
+
+		Context("given a version scheme", func() {
+			BeforeEach(func() {
+				azureClient.ListBlobsReturnsOnCall(0, storage.BlobListResponse{
+					Blobs: []storage.Blob{
+						storage.Blob{Name: "example-1.10.0.json"},
+						storage.Blob{Name: "example-1.2.0-rc.1.json"},
+						storage.Blob{Name: "example-1.2.0.json"},
+						storage.Blob{Name: "example-1.9.json"},
+					},
+				}, nil)
+			})
+
+			It("orders the versions by the scheme", func() {
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{
+					Scheme:         api.VersionSchemeSemver,
+					SkipUnparsable: true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(latestVersions).To(HaveLen(3))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("1.2.0-rc.1")))
+				Expect(latestVersions[1].Version).To(Equal(stringPtr("1.2.0")))
+				Expect(latestVersions[2].Version).To(Equal(stringPtr("1.10.0")))
+			})
+
+			It("returns an error for versions outside the scheme", func() {
+				_, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{
+					Scheme: api.VersionSchemeSemver,
+				})
+				Expect(err).To(MatchError(`expected version "1.9" to be a semantic version`))
+			})
+
+			It("orders lexically", func() {
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.2.0", api.RegexpOptions{
+					Scheme: api.VersionSchemeLexical,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(latestVersions).To(HaveLen(3))
+				Expect(latestVersions[0].Version).To(Equal(stringPtr("1.2.0")))
+				Expect(latestVersions[1].Version).To(Equal(stringPtr("1.2.0-rc.1")))
+				Expect(latestVersions[2].Version).To(Equal(stringPtr("1.9")))
+			})
+		})
+
+		Context("given a regex pattern with unparsable versions", func() {
+			BeforeEach(func() {
+				azureClient.ListBlobsReturns(storage.BlobListResponse{
+					Blobs: []storage.Blob{
+						storage.Blob{
+							Name: "backup-2024-05-02T0300.tar",
+							Properties: storage.BlobProperties{
+								LastModified: storage.TimeRFC1123(time.Date(2024, 5, 2, 3, 5, 0, 0, time.UTC)),
+							},
+						},
+						storage.Blob{
+							Name: "backup-2024-05-01T0300.tar",
+							Properties: storage.BlobProperties{
+								LastModified: storage.TimeRFC1123(time.Date(2024, 5, 3, 3, 5, 0, 0, time.UTC)),
+							},
+						},
+						storage.Blob{
+							Name: "backup-2024-05-03T0300#partial.tar",
+							Properties: storage.BlobProperties{
+								LastModified: storage.TimeRFC1123(time.Date(2024, 5, 1, 3, 5, 0, 0, time.UTC)),
+							},
+						},
+					},
+				}, nil)
+			})
+
+			It("returns an error", func() {
+				_, err := check.VersionsSinceRegexp("backup-(.*).tar", "", api.RegexpOptions{})
+				Expect(err).To(MatchError(ContainSubstring("Expected version '2024-05-03T0300#partial' to match version format")))
+			})
+
+			It("skips the unparsable versions when asked to", func() {
+				latestVersions, err := check.VersionsSinceRegexp("backup-(.*).tar", "", api.RegexpOptions{SkipUnparsable: true})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(latestVersions).To(HaveLen(2))
+				Expect(latestVersions[0].Path).To(Equal(stringPtr("backup-2024-05-01T0300.tar")))
+				Expect(latestVersions[1].Path).To(Equal(stringPtr("backup-2024-05-02T0300.tar")))
+			})
+
+			Context("when ordering by last modified time", func() {
+				It("returns the blobs modified since the given time", func() {
+					latestVersions, err := check.VersionsSinceRegexp("backup-.*.tar", "2024-05-02T03:05:00Z", api.RegexpOptions{
+						OrderBy: api.OrderByLastModified,
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(latestVersions).To(HaveLen(2))
+					Expect(latestVersions[0].Path).To(Equal(stringPtr("backup-2024-05-02T0300.tar")))
+					Expect(latestVersions[0].Version).To(Equal(stringPtr("2024-05-02T03:05:00Z")))
+					Expect(latestVersions[1].Path).To(Equal(stringPtr("backup-2024-05-01T0300.tar")))
+					Expect(latestVersions[1].Version).To(Equal(stringPtr("2024-05-03T03:05:00Z")))
+				})
+			})
+
+			Context("when ordering by captured time", func() {
+				var options api.RegexpOptions
+
+				BeforeEach(func() {
+					options = api.RegexpOptions{
+						OrderBy:           api.OrderByCaptureTime,
+						CaptureTimeLayout: "2006-01-02T1504",
+					}
+				})
+
+				It("returns an error for captures not matching the layout", func() {
+					_, err := check.VersionsSinceRegexp("backup-(.*).tar", "", options)
+					Expect(err).To(MatchError(ContainSubstring(`extra text: "#partial"`)))
+				})
+
+				It("returns the blobs captured since the given time", func() {
+					options.SkipUnparsable = true
+
+					latestVersions, err := check.VersionsSinceRegexp("backup-(.*).tar", "", options)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(latestVersions).To(HaveLen(2))
+					Expect(latestVersions[0].Path).To(Equal(stringPtr("backup-2024-05-01T0300.tar")))
+					Expect(latestVersions[0].Version).To(Equal(stringPtr("2024-05-01T0300")))
+					Expect(latestVersions[1].Path).To(Equal(stringPtr("backup-2024-05-02T0300.tar")))
+
+					latestVersions, err = check.VersionsSinceRegexp("backup-(.*).tar", "2024-05-02T0300", options)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(latestVersions).To(HaveLen(1))
+					Expect(latestVersions[0].Version).To(Equal(stringPtr("2024-05-02T0300")))
+				})
+			})
 		})
 
 		Context("given a regex pattern with numbered blobs", func() {
@@ -242,7 +1029,7 @@ var _ = Describe("Check", func() {
 			})
 
 			It("returns all the blob matching the regex pattern newer than given version", func() {
-				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "2")
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "2", api.RegexpOptions{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(latestVersions).To(HaveLen(2))
@@ -274,7 +1061,7 @@ var _ = Describe("Check", func() {
 			})
 
 			It("returns a version using the first group as the version", func() {
-				latestVersions, err := check.VersionsSinceRegexp("example-.-(.*)-(.).json", "")
+				latestVersions, err := check.VersionsSinceRegexp("example-.-(.*)-(.).json", "", api.RegexpOptions{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(latestVersions[len(latestVersions)-1].Path).To(Equal(stringPtr("example-b-1.2.3-b.json")))
@@ -282,7 +1069,7 @@ var _ = Describe("Check", func() {
 
 			Context("when a group is named version", func() {
 				It("returns a version using the named group as the version", func() {
-					latestVersions, err := check.VersionsSinceRegexp("example-(.)-(?P<version>.*)-..json", "")
+					latestVersions, err := check.VersionsSinceRegexp("example-(.)-(?P<version>.*)-..json", "", api.RegexpOptions{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(latestVersions[len(latestVersions)-1].Path).To(Equal(stringPtr("example-b-1.2.3-b.json")))
@@ -302,11 +1089,45 @@ var _ = Describe("Check", func() {
 			})
 
 			It("returns a version using the first group as the version", func() {
-				_, err := check.VersionsSinceRegexp("example-(.*).json", "")
+				_, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{})
 				Expect(err).To(MatchError("no matching blob found for regexp: example-(.*).json"))
 			})
 		})
 
+		Context("when the regexp starts with literal text", func() {
+			It("lists only the blobs starting with that text", func() {
+				_, err := check.VersionsSinceRegexp(`^releases/product-v(\d+\.\d+\.\d+)\.tgz`, "", api.RegexpOptions{})
+				Expect(err).To(HaveOccurred())
+
+				Expect(azureClient.ListBlobsCallCount()).To(Equal(1))
+				Expect(azureClient.ListBlobsArgsForCall(0).Prefix).To(Equal("releases/product-v"))
+			})
+
+			It("lists all blobs when the regexp is not anchored", func() {
+				azureClient.ListBlobsReturnsOnCall(0, storage.BlobListResponse{
+					Blobs: []storage.Blob{
+						storage.Blob{Name: "builds/release-1.0.0.tgz"},
+					},
+				}, nil)
+
+				latestVersions, err := check.VersionsSinceRegexp(`release-(.*).tgz`, "", api.RegexpOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(azureClient.ListBlobsCallCount()).To(Equal(1))
+				Expect(azureClient.ListBlobsArgsForCall(0).Prefix).To(BeEmpty())
+				Expect(latestVersions).To(HaveLen(1))
+				Expect(latestVersions[0].Path).To(Equal(stringPtr("builds/release-1.0.0.tgz")))
+			})
+
+			It("lists all blobs when the text is case insensitive", func() {
+				_, err := check.VersionsSinceRegexp(`(?i)^releases/product-(.*)\.tgz`, "", api.RegexpOptions{})
+				Expect(err).To(HaveOccurred())
+
+				Expect(azureClient.ListBlobsCallCount()).To(Equal(1))
+				Expect(azureClient.ListBlobsArgsForCall(0).Prefix).To(BeEmpty())
+			})
+		})
+
 		Context("with pagination", func() {
 			BeforeEach(func() {
 				azureClient.ListBlobsReturnsOnCall(0, storage.BlobListResponse{
@@ -340,13 +1161,13 @@ var _ = Describe("Check", func() {
 			})
 
 			It("returns all the blobs matching the regex pattern newer than given version", func() {
-				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.2.0")
+				latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.2.0", api.RegexpOptions{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(azureClient.ListBlobsCallCount()).To(Equal(2))
 				Expect(azureClient.ListBlobsArgsForCall(0)).To(Equal(storage.ListBlobsParameters{
 					Include: &storage.IncludeBlobDataset{
-						Snapshots:        true,
+						Snapshots:        false,
 						Metadata:         false,
 						UncommittedBlobs: false,
 						Copy:             true,
@@ -354,7 +1175,7 @@ var _ = Describe("Check", func() {
 				}))
 				Expect(azureClient.ListBlobsArgsForCall(1)).To(Equal(storage.ListBlobsParameters{
 					Include: &storage.IncludeBlobDataset{
-						Snapshots:        true,
+						Snapshots:        false,
 						Metadata:         false,
 						UncommittedBlobs: false,
 						Copy:             true,
@@ -379,7 +1200,7 @@ var _ = Describe("Check", func() {
 				})
 
 				It("returns results that exist on the next page", func() {
-					latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.2.0")
+					latestVersions, err := check.VersionsSinceRegexp("example-(.*).json", "1.2.0", api.RegexpOptions{})
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(latestVersions).To(HaveLen(1))
@@ -397,14 +1218,14 @@ var _ = Describe("Check", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := check.VersionsSinceRegexp("example-(.*).json", "")
+				_, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{})
 				Expect(err).To(MatchError("something bad happened"))
 			})
 		})
 
 		Context("when an invalid regex pattern is provided", func() {
 			It("returns an error", func() {
-				_, err := check.VersionsSinceRegexp("example-(.json", "")
+				_, err := check.VersionsSinceRegexp("example-(.json", "", api.RegexpOptions{})
 				Expect(err).To(MatchError("error parsing regexp: missing closing ): `example-(.json`"))
 			})
 		})
@@ -421,7 +1242,7 @@ var _ = Describe("Check", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := check.VersionsSinceRegexp("example-(.*).json", "")
+				_, err := check.VersionsSinceRegexp("example-(.*).json", "", api.RegexpOptions{})
 				Expect(err).To(MatchError("Expected version '%' to match version format"))
 			})
 		})
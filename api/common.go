@@ -16,6 +16,26 @@ func URLAppendTimeStamp(baseURL string, snapshot time.Time) (string, error) {
 	return u.String(), nil
 }
 
+// FormatVersionID formats t as Azure formats snapshot and version ids. The
+// zero time formats as the empty string.
+func FormatVersionID(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.0000000Z")
+}
+
+func URLAppendVersionID(baseURL string, versionID string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("versionid", versionID)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 func FindSubexpression(subexps []string, searchFor string) (int, bool) {
 	for i, item := range subexps {
 		if item == searchFor {
@@ -30,4 +30,22 @@ var _ = Describe("Common", func() {
 
 	})
 
+	Describe("FormatVersionID", func() {
+		It("formats the time as a version id", func() {
+			Expect(FormatVersionID(time.Date(2017, 1, 2, 3, 4, 5, 600000*1000, time.UTC))).To(Equal("2017-01-02T03:04:05.6000000Z"))
+		})
+
+		It("formats the zero time as the empty string", func() {
+			Expect(FormatVersionID(time.Time{})).To(BeEmpty())
+		})
+	})
+
+	Describe("URLAppendVersionID", func() {
+		It("appends the version id", func() {
+			url, err := URLAppendVersionID("http://example.com/container/example.json", "2017-01-02T03:04:05.6000000Z")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(url).To(Equal("http://example.com/container/example.json?versionid=2017-01-02T03%3A04%3A05.6000000Z"))
+		})
+	})
+
 })
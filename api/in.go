@@ -36,6 +36,31 @@ func (i In) CopyBlobToDestination(destinationDir, blobName string, snapshot *tim
 	return i.azureClient.DownloadBlobToFile(blobName, file, snapshot, blockSize, retryTryTimeout)
 }
 
+// CopyBlobToDestinationIfUnchanged copies blobName only while it still has
+// the given etag or, when etag is empty, the given last modified time, so
+// that get never fetches content that differs from the requested version.
+func (i In) CopyBlobToDestinationIfUnchanged(destinationDir, blobName, etag string, lastModified time.Time, blockSize int64, retryTryTimeout time.Duration) error {
+	fileName := path.Base(blobName)
+	file, err := os.Create(filepath.Join(destinationDir, fileName))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return i.azureClient.DownloadBlobToFileIfUnchanged(blobName, file, etag, lastModified, blockSize, retryTryTimeout)
+}
+
+func (i In) CopyBlobVersionToDestination(destinationDir, blobName, versionID string, blockSize int64, retryTryTimeout time.Duration) error {
+	fileName := path.Base(blobName)
+	file, err := os.Create(filepath.Join(destinationDir, fileName))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return i.azureClient.DownloadBlobVersionToFile(blobName, versionID, file, blockSize, retryTryTimeout)
+}
+
 func (i In) UnpackBlob(filename string) error {
 	var cmd *exec.Cmd
 
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
 	"github.com/pivotal-cf/azure-blobstore-resource/azure/azurefakes"
 
 	"github.com/pivotal-cf/azure-blobstore-resource/api"
@@ -87,6 +88,55 @@ var _ = Describe("In", func() {
 		})
 	})
 
+	Describe("CopyBlobVersionToDestination", func() {
+		It("copies the blob version from azure blobstore to local destination directory", func() {
+			err := in.CopyBlobVersionToDestination(tempDir, "example.json", "2017-01-01T01:01:01.0000001Z", 1, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.DownloadBlobVersionToFileCallCount()).To(Equal(1))
+
+			blobName, versionID, file, blockSize, retryTryTimeout := azureClient.DownloadBlobVersionToFileArgsForCall(0)
+			Expect(blobName).To(Equal("example.json"))
+			Expect(versionID).To(Equal("2017-01-01T01:01:01.0000001Z"))
+			Expect(path.Base(file.Name())).To(Equal("example.json"))
+			Expect(blockSize).To(Equal(int64(1)))
+			Expect(retryTryTimeout).To(Equal(time.Second))
+		})
+
+		It("returns an error when the blob version cannot be downloaded", func() {
+			azureClient.DownloadBlobVersionToFileReturns(errors.New("failed to download blob version"))
+
+			err := in.CopyBlobVersionToDestination(tempDir, "example.json", "2017-01-01T01:01:01.0000001Z", 1, time.Second)
+			Expect(err).To(MatchError("failed to download blob version"))
+		})
+	})
+
+	Describe("CopyBlobToDestinationIfUnchanged", func() {
+		It("copies the blob only if it still has the requested etag or last modified time", func() {
+			lastModified := time.Date(2017, time.January, 1, 1, 1, 1, 0, time.UTC)
+
+			err := in.CopyBlobToDestinationIfUnchanged(tempDir, "example.json", "0x8D4BCC2E4835CD0", lastModified, 1, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(azureClient.DownloadBlobToFileIfUnchangedCallCount()).To(Equal(1))
+
+			blobName, file, etag, passedLastModified, blockSize, retryTryTimeout := azureClient.DownloadBlobToFileIfUnchangedArgsForCall(0)
+			Expect(blobName).To(Equal("example.json"))
+			Expect(path.Base(file.Name())).To(Equal("example.json"))
+			Expect(etag).To(Equal("0x8D4BCC2E4835CD0"))
+			Expect(passedLastModified).To(Equal(lastModified))
+			Expect(blockSize).To(Equal(int64(1)))
+			Expect(retryTryTimeout).To(Equal(time.Second))
+		})
+
+		It("returns an error when the blob has been overwritten", func() {
+			azureClient.DownloadBlobToFileIfUnchangedReturns(azure.ErrVersionNotAvailable)
+
+			err := in.CopyBlobToDestinationIfUnchanged(tempDir, "example.json", "0x8D4BCC2E4835CD0", time.Time{}, 1, time.Second)
+			Expect(err).To(MatchError(azure.ErrVersionNotAvailable))
+		})
+	})
+
 	Describe("UnpackBlob", func() {
 		DescribeTable("unpacks the blob successfully", func(fixtureFilename, innerFilename, innerFileContents string) {
 			err := copyFile(filepath.Join("fixtures", fixtureFilename), filepath.Join(tempDir, fixtureFilename))
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
 )
 
 type azureClient interface {
@@ -14,6 +15,15 @@ type azureClient interface {
 	GetBlobSizeInBytes(blobName string, snapshop time.Time) (int64, error)
 	UploadFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) error
 	DownloadBlobToFile(blobName string, file *os.File, snapshop *time.Time, blockSize int64, retryTryTimeout time.Duration) error
+	DownloadBlobToFileIfUnchanged(blobName string, file *os.File, etag string, lastModified time.Time, blockSize int64, retryTryTimeout time.Duration) error
 	CreateSnapshot(blobName string) (time.Time, error)
 	GetBlobURL(blobName string) (string, error)
+	ListBlobVersions(prefix string) ([]azure.BlobVersion, error)
+	DownloadBlobVersionToFile(blobName, versionID string, file *os.File, blockSize int64, retryTryTimeout time.Duration) error
+	UploadBlobVersionFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) (string, error)
+	UploadBlobETagFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) (string, time.Time, error)
+	FindBlobsByTags(expression string) ([]azure.TaggedBlob, error)
+	GetBlobTags(blobName string) (map[string]string, error)
+	ListBlobsWithStates(params storage.ListBlobsParameters) (storage.BlobListResponse, []azure.BlobState, error)
+	GetBlobState(blobName string) (azure.BlobState, error)
 }
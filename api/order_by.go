@@ -0,0 +1,34 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// OrderBy decides how check orders the blobs matched by a regexp.
+type OrderBy string
+
+const (
+	OrderByVersion      OrderBy = "version"
+	OrderByLastModified OrderBy = "last_modified"
+	OrderByCaptureTime  OrderBy = "capture_time"
+)
+
+// ParseOrderBy parses the order_by source parameter, which defaults to
+// ordering by version.
+func ParseOrderBy(orderBy string) (OrderBy, error) {
+	switch OrderBy(orderBy) {
+	case "":
+		return OrderByVersion, nil
+	case OrderByVersion, OrderByLastModified, OrderByCaptureTime:
+		return OrderBy(orderBy), nil
+	default:
+		return "", fmt.Errorf("order_by must be one of version, last_modified or capture_time: %q", orderBy)
+	}
+}
+
+// LastModifiedVersion formats the last modified time of a blob as the
+// version reported when ordering by last_modified.
+func LastModifiedVersion(lastModified time.Time) string {
+	return lastModified.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,30 @@
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/api"
+)
+
+var _ = Describe("ParseOrderBy", func() {
+	It("defaults to versions", func() {
+		orderBy, err := api.ParseOrderBy("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(orderBy).To(Equal(api.OrderByVersion))
+	})
+
+	It("accepts last modified and capture times", func() {
+		orderBy, err := api.ParseOrderBy("last_modified")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(orderBy).To(Equal(api.OrderByLastModified))
+
+		orderBy, err = api.ParseOrderBy("capture_time")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(orderBy).To(Equal(api.OrderByCaptureTime))
+	})
+
+	It("returns an error for an unknown value", func() {
+		_, err := api.ParseOrderBy("name")
+		Expect(err).To(MatchError(`order_by must be one of version, last_modified or capture_time: "name"`))
+	})
+})
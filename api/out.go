@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 )
 
@@ -18,24 +19,11 @@ func NewOut(azureClient azureClient) Out {
 }
 
 func (o Out) UploadFileToBlobstore(sourceDirectory string, filename string, blobName string, createSnapshot bool, blockSize int, retryTryTimeout time.Duration) (string, *time.Time, error) {
-	matches, err := filepath.Glob(filepath.Join(sourceDirectory, filename))
+	fileToUpload, blobName, err := findFileToUpload(sourceDirectory, filename, blobName, createSnapshot)
 	if err != nil {
-		// not tested
 		return "", nil, err
 	}
 
-	var fileToUpload string
-	if len(matches) == 0 {
-		fileToUpload = filepath.Join(sourceDirectory, filename)
-	} else if len(matches) == 1 {
-		fileToUpload = matches[0]
-		if !createSnapshot {
-			blobName = filepath.Join(filepath.Dir(blobName), filepath.Base(fileToUpload))
-		}
-	} else {
-		return "", nil, fmt.Errorf("multiple files match glob: %s", filename)
-	}
-
 	file, err := os.Open(fileToUpload)
 	if err != nil {
 		return "", nil, err
@@ -58,3 +46,98 @@ func (o Out) UploadFileToBlobstore(sourceDirectory string, filename string, blob
 
 	return blobName, nil, nil
 }
+
+// UploadFileToBlobstoreVersion uploads the file to blobName in a storage
+// account with blob versioning enabled and returns the id of the new version.
+func (o Out) UploadFileToBlobstoreVersion(sourceDirectory string, filename string, blobName string, blockSize int, retryTryTimeout time.Duration) (string, string, error) {
+	fileToUpload, blobName, err := findFileToUpload(sourceDirectory, filename, blobName, true)
+	if err != nil {
+		return "", "", err
+	}
+
+	file, err := os.Open(fileToUpload)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	versionID, err := o.azureClient.UploadBlobVersionFromStream(blobName, file, blockSize, retryTryTimeout)
+	if err != nil {
+		return "", "", err
+	}
+
+	return blobName, versionID, nil
+}
+
+// UploadFileToBlobstoreETag uploads the file to blobName without creating a
+// snapshot and returns the ETag and last modified time of the uploaded blob.
+// Unless keepBlobName is set, the blob is named after the uploaded file.
+func (o Out) UploadFileToBlobstoreETag(sourceDirectory string, filename string, blobName string, keepBlobName bool, blockSize int, retryTryTimeout time.Duration) (string, string, time.Time, error) {
+	fileToUpload, blobName, err := findFileToUpload(sourceDirectory, filename, blobName, keepBlobName)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	file, err := os.Open(fileToUpload)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	defer file.Close()
+
+	etag, lastModified, err := o.azureClient.UploadBlobETagFromStream(blobName, file, blockSize, retryTryTimeout)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return blobName, etag, lastModified, nil
+}
+
+// UploadedVersion returns the version out reports for the blob it uploaded to
+// path when versions are matched by regexp, or "" when there is none. With
+// metadataKey check reads versions from metadata that out does not write, so
+// only the path is reported rather than a version check never produces.
+func UploadedVersion(matcher *regexp.Regexp, path string, lastModified time.Time, metadataKey string, options RegexpOptions) (string, error) {
+	if metadataKey != "" {
+		return "", nil
+	}
+
+	if options.OrderBy == OrderByLastModified {
+		return LastModifiedVersion(lastModified), nil
+	}
+
+	// No error if the regexp doesn't match to preserve behaviour that the
+	// resource doesn't error if the regex doesn't find a match in the
+	// uploaded blob path
+	match, ok := RegexpVersion(matcher, path)
+	if !ok {
+		return "", nil
+	}
+
+	if options.OrderBy == OrderByCaptureTime {
+		return match, nil
+	}
+
+	return options.Scheme.FormatVersion(match)
+}
+
+// findFileToUpload expands the filename glob in sourceDirectory. Unless
+// keepBlobName is set, the blob is named after the matched file.
+func findFileToUpload(sourceDirectory, filename, blobName string, keepBlobName bool) (string, string, error) {
+	matches, err := filepath.Glob(filepath.Join(sourceDirectory, filename))
+	if err != nil {
+		// not tested
+		return "", "", err
+	}
+
+	if len(matches) == 0 {
+		return filepath.Join(sourceDirectory, filename), blobName, nil
+	} else if len(matches) > 1 {
+		return "", "", fmt.Errorf("multiple files match glob: %s", filename)
+	}
+
+	if !keepBlobName {
+		blobName = filepath.Join(filepath.Dir(blobName), filepath.Base(matches[0]))
+	}
+
+	return matches[0], blobName, nil
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	"github.com/pivotal-cf/azure-blobstore-resource/azure/azurefakes"
@@ -182,4 +183,102 @@ var _ = Describe("Out", func() {
 			})
 		})
 	})
+
+	Describe("UploadFileToBlobstoreETag", func() {
+		It("uploads the file and returns the etag and last modified time of the blob", func() {
+			lastModified := time.Date(2017, time.January, 1, 1, 1, 1, 0, time.UTC)
+			azureClient.UploadBlobETagFromStreamReturns("0x8D4BCC2E4835CD0", lastModified, nil)
+
+			path, etag, passedLastModified, err := out.UploadFileToBlobstoreETag(tempDir, "*.json", "versioned.json", true, 1, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("versioned.json"))
+			Expect(etag).To(Equal("0x8D4BCC2E4835CD0"))
+			Expect(passedLastModified).To(Equal(lastModified))
+
+			Expect(azureClient.UploadBlobETagFromStreamCallCount()).To(Equal(1))
+			blobName, _, blockSize, retryTryTimeout := azureClient.UploadBlobETagFromStreamArgsForCall(0)
+			Expect(blobName).To(Equal("versioned.json"))
+			Expect(blockSize).To(Equal(1))
+			Expect(retryTryTimeout).To(Equal(time.Second))
+
+			Expect(azureClient.ListBlobsCallCount()).To(Equal(0))
+			Expect(azureClient.CreateSnapshotCallCount()).To(Equal(0))
+		})
+
+		It("names the blob after the uploaded file unless the blob name is kept", func() {
+			path, _, _, err := out.UploadFileToBlobstoreETag(tempDir, "*.json", "some-dir/example-*.json", false, 1, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("some-dir/example.json"))
+		})
+
+		It("returns an error when the upload fails", func() {
+			azureClient.UploadBlobETagFromStreamReturns("", time.Time{}, errors.New("failed to upload blob"))
+
+			_, _, _, err := out.UploadFileToBlobstoreETag(tempDir, "example.json", "example.json", true, 1, time.Second)
+			Expect(err).To(MatchError("failed to upload blob"))
+		})
+	})
+
+	Describe("UploadFileToBlobstoreVersion", func() {
+		It("uploads the file and returns the new version id", func() {
+			azureClient.UploadBlobVersionFromStreamReturns("2017-01-01T01:01:01.0000001Z", nil)
+
+			path, versionID, err := out.UploadFileToBlobstoreVersion(tempDir, "*.json", "versioned.json", 1, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("versioned.json"))
+			Expect(versionID).To(Equal("2017-01-01T01:01:01.0000001Z"))
+
+			Expect(azureClient.UploadBlobVersionFromStreamCallCount()).To(Equal(1))
+			blobName, _, blockSize, retryTryTimeout := azureClient.UploadBlobVersionFromStreamArgsForCall(0)
+			Expect(blobName).To(Equal("versioned.json"))
+			Expect(blockSize).To(Equal(1))
+			Expect(retryTryTimeout).To(Equal(time.Second))
+
+			Expect(azureClient.UploadFromStreamCallCount()).To(Equal(0))
+			Expect(azureClient.CreateSnapshotCallCount()).To(Equal(0))
+		})
+
+		It("returns an error when the upload fails", func() {
+			azureClient.UploadBlobVersionFromStreamReturns("", errors.New("failed to upload blob"))
+
+			_, _, err := out.UploadFileToBlobstoreVersion(tempDir, "example.json", "example.json", 1, time.Second)
+			Expect(err).To(MatchError("failed to upload blob"))
+		})
+	})
+
+	Describe("UploadedVersion", func() {
+		var matcher *regexp.Regexp
+
+		BeforeEach(func() {
+			matcher = regexp.MustCompile(`releases/product-(.*)\.tgz`)
+		})
+
+		It("returns the version matched by the regexp in the scheme's format", func() {
+			ver, err := api.UploadedVersion(matcher, "releases/product-1.2.0.tgz", time.Time{}, "", api.RegexpOptions{Scheme: api.VersionSchemeSemver})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ver).To(Equal("1.2.0"))
+		})
+
+		It("returns the last modified time with order_by last_modified", func() {
+			lastModified := time.Date(2024, time.May, 1, 3, 5, 0, 0, time.UTC)
+
+			ver, err := api.UploadedVersion(matcher, "releases/product-1.2.0.tgz", lastModified, "", api.RegexpOptions{OrderBy: api.OrderByLastModified})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ver).To(Equal(api.LastModifiedVersion(lastModified)))
+		})
+
+		It("returns no version when the regexp does not match", func() {
+			ver, err := api.UploadedVersion(matcher, "notes/product.txt", time.Time{}, "", api.RegexpOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ver).To(BeEmpty())
+		})
+
+		It("returns no version from the name when versions are read from metadata", func() {
+			matcher = regexp.MustCompile(`releases/product-([0-9a-f]+)\.tgz`)
+
+			ver, err := api.UploadedVersion(matcher, "releases/product-3fa9c12.tgz", time.Time{}, "version", api.RegexpOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ver).To(BeEmpty())
+		})
+	})
 })
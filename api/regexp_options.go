@@ -0,0 +1,82 @@
+package api
+
+import (
+	"strings"
+)
+
+// PrereleasePolicy decides whether check reports prerelease versions.
+type PrereleasePolicy string
+
+const (
+	PrereleasesInclude PrereleasePolicy = "include"
+	PrereleasesExclude PrereleasePolicy = "exclude"
+	PrereleasesOnly    PrereleasePolicy = "only"
+)
+
+// RegexpOptions controls which of the versions matched by a regexp are
+// reported by check and how they are ordered.
+type RegexpOptions struct {
+	Scheme VersionScheme
+
+	Constraint VersionConstraint
+
+	Prereleases PrereleasePolicy
+
+	// PrereleaseSuffixes marks versions containing any of the suffixes, e.g.
+	// "+build." in "1.2.0+build.7", as prereleases of the version before the
+	// suffix.
+	PrereleaseSuffixes []string
+
+	OrderBy OrderBy
+
+	// CaptureTimeLayout is the time layout, in the format of the time
+	// package, of the versions matched when ordering by capture_time.
+	CaptureTimeLayout string
+
+	// SkipUnparsable ignores blobs whose version cannot be parsed instead of
+	// failing the check.
+	SkipUnparsable bool
+
+	// InitialVersion is the oldest version reported, in the format of the
+	// versions being ordered.
+	InitialVersion string
+}
+
+// comparableVersion parses a matched version, moving any configured
+// prerelease suffix into the prerelease segment so that it orders before the
+// release it precedes.
+func (o RegexpOptions) comparableVersion(match string) (schemeVersion, error) {
+	if !o.Scheme.supportsPrereleaseSuffixes() {
+		return o.Scheme.parse(match)
+	}
+
+	index := -1
+	for _, suffix := range o.PrereleaseSuffixes {
+		i := strings.Index(match, suffix)
+		if i > 0 && (index == -1 || i < index) {
+			index = i
+		}
+	}
+
+	if index == -1 {
+		return o.Scheme.parse(match)
+	}
+
+	preRelease := strings.TrimLeft(match[index:], "-+._")
+	return o.Scheme.parse(match[:index] + "-" + preRelease)
+}
+
+func (o RegexpOptions) allows(ver schemeVersion) bool {
+	switch o.Prereleases {
+	case PrereleasesExclude:
+		if ver.isPreRelease() {
+			return false
+		}
+	case PrereleasesOnly:
+		if !ver.isPreRelease() {
+			return false
+		}
+	}
+
+	return o.Constraint.allows(ver)
+}
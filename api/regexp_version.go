@@ -0,0 +1,87 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+)
+
+// VersionGroups are the names of the capture groups that are combined, in
+// order, into a compound version, e.g. "3.1.452" for "app_3.1_b452.tgz"
+// matched by `app_(?P<major>\d+)\.(?P<minor>\d+)_b(?P<build>\d+)\.tgz`.
+var VersionGroups = []string{"major", "minor", "patch", "build"}
+
+// RegexpVersion returns the version matched by matcher in name. When the
+// regexp has any of the VersionGroups, the version joins them with dots, an
+// unmatched group counting as zero and a group named "version" standing in
+// for major, minor and patch. Otherwise it is the group named "version", or
+// the first group.
+func RegexpVersion(matcher *regexp.Regexp, name string) (string, bool) {
+	matches := matcher.FindStringSubmatch(name)
+	if len(matches) < 2 {
+		return "", false
+	}
+
+	names := matcher.SubexpNames()
+	versionIndex, hasVersion := FindSubexpression(names, "version")
+
+	var parts []string
+	for _, group := range VersionGroups {
+		index, found := FindSubexpression(names, group)
+		if !found || (hasVersion && group != "build") {
+			continue
+		}
+
+		part := matches[index]
+		if part == "" {
+			part = "0"
+		}
+		parts = append(parts, part)
+	}
+
+	if len(parts) > 0 {
+		if hasVersion {
+			parts = append([]string{matches[versionIndex]}, parts...)
+		}
+		return strings.Join(parts, "."), true
+	}
+
+	if hasVersion {
+		return matches[versionIndex], true
+	}
+
+	return matches[1], true
+}
+
+// hasVersionGroups reports whether the regexp has any of the VersionGroups.
+// The compound version they are joined into is only valid semi-semantic.
+func hasVersionGroups(matcher *regexp.Regexp) bool {
+	names := matcher.SubexpNames()
+	for _, group := range VersionGroups {
+		if _, found := FindSubexpression(names, group); found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RegexpVersionGroups returns the text matched in name by each of the
+// VersionGroups the regexp has.
+func RegexpVersionGroups(matcher *regexp.Regexp, name string) map[string]string {
+	groups := map[string]string{}
+
+	matches := matcher.FindStringSubmatch(name)
+	if matches == nil {
+		return groups
+	}
+
+	names := matcher.SubexpNames()
+	for _, group := range VersionGroups {
+		index, found := FindSubexpression(names, group)
+		if found {
+			groups[group] = matches[index]
+		}
+	}
+
+	return groups
+}
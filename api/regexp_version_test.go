@@ -0,0 +1,45 @@
+package api_test
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/api"
+)
+
+var _ = Describe("RegexpVersion", func() {
+	DescribeTable("the matched version",
+		func(expr, name, expected string) {
+			ver, ok := api.RegexpVersion(regexp.MustCompile(expr), name)
+			Expect(ok).To(BeTrue())
+			Expect(ver).To(Equal(expected))
+		},
+		Entry("first group", `app-(.*)-(.*)\.tgz`, "app-1.2.3-linux.tgz", "1.2.3"),
+		Entry("group named version", `app-(.*)-(?P<version>.*)\.tgz`, "app-linux-1.2.3.tgz", "1.2.3"),
+		Entry("compound groups", `app_(?P<major>\d+)\.(?P<minor>\d+)_b(?P<build>\d+)\.tgz`, "app_3.1_b452.tgz", "3.1.452"),
+		Entry("unmatched compound group", `app_(?P<major>\d+)(?:\.(?P<minor>\d+))?_b(?P<build>\d+)\.tgz`, "app_3_b452.tgz", "3.0.452"),
+		Entry("version and build groups", `app-(?P<version>[\d.]+)\+(?P<build>\d+)\.tgz`, "app-1.2.3+17.tgz", "1.2.3.17"),
+	)
+
+	It("does not match names without a group match", func() {
+		_, ok := api.RegexpVersion(regexp.MustCompile(`app-(.*)\.tgz`), "other.tgz")
+		Expect(ok).To(BeFalse())
+	})
+
+	Describe("RegexpVersionGroups", func() {
+		It("returns the compound groups of the regexp", func() {
+			groups := api.RegexpVersionGroups(
+				regexp.MustCompile(`app_(?P<major>\d+)\.(?P<minor>\d+)_b(?P<build>\d+)\.tgz`),
+				"app_3.1_b452.tgz",
+			)
+			Expect(groups).To(Equal(map[string]string{"major": "3", "minor": "1", "build": "452"}))
+		})
+
+		It("returns no groups when the name does not match", func() {
+			groups := api.RegexpVersionGroups(regexp.MustCompile(`app_(?P<major>\d+)\.tgz`), "other.tgz")
+			Expect(groups).To(BeEmpty())
+		})
+	})
+})
@@ -1,9 +1,15 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
 	"time"
 
 	"github.com/pivotal-cf/azure-blobstore-resource/api/internal/types"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
 )
 
 type InRequest struct {
@@ -18,18 +24,309 @@ type OutRequest struct {
 }
 
 type RequestSource struct {
-	BaseURL            string `json:"base_url"`
-	StorageAccountName string `json:"storage_account_name"`
-	StorageAccountKey  string `json:"storage_account_key"`
-	Container          string `json:"container"`
-	VersionedFile      string `json:"versioned_file"`
-	Regexp             string `json:"regexp"`
+	Environment                string   `json:"environment"`
+	BaseURL                    string   `json:"base_url"`
+	BlobEndpoint               string   `json:"blob_endpoint"`
+	StorageAccountName         string   `json:"storage_account_name"`
+	StorageAccountKey          string   `json:"storage_account_key"`
+	SecondaryStorageAccountKey string   `json:"secondary_storage_account_key"`
+	SASToken                   string   `json:"sas_token"`
+	ConnectionString           string   `json:"connection_string"`
+	TenantID                   string   `json:"tenant_id"`
+	ClientID                   string   `json:"client_id"`
+	ClientSecret               string   `json:"client_secret"`
+	ClientCertificate          string   `json:"client_certificate"`
+	UseWorkloadIdentity        bool     `json:"use_workload_identity"`
+	FederatedTokenFile         string   `json:"federated_token_file"`
+	UseManagedIdentity         bool     `json:"use_managed_identity"`
+	ManagedIdentityClientID    string   `json:"managed_identity_client_id"`
+	ManagedIdentityEndpoint    string   `json:"managed_identity_endpoint"`
+	ProxyURL                   string   `json:"proxy_url"`
+	NoProxy                    string   `json:"no_proxy"`
+	CACerts                    string   `json:"ca_certs"`
+	ReadFromSecondary          bool     `json:"read_from_secondary"`
+	Container                  string   `json:"container"`
+	VersionedFile              string   `json:"versioned_file"`
+	VersionBy                  string   `json:"version_by"`
+	AllowMissing               bool     `json:"allow_missing"`
+	Regexp                     string   `json:"regexp"`
+	TagFilter                  string   `json:"tag_filter"`
+	VersionTag                 string   `json:"version_tag"`
+	VersionMetadataKey         string   `json:"version_metadata_key"`
+	VersionScheme              string   `json:"version_scheme"`
+	VersionConstraint          string   `json:"version_constraint"`
+	Prereleases                string   `json:"prereleases"`
+	PrereleaseSuffixes         []string `json:"prerelease_suffixes"`
+	OrderBy                    string   `json:"order_by"`
+	CaptureTimeLayout          string   `json:"capture_time_layout"`
+	SkipUnparsableVersions     bool     `json:"skip_unparsable_versions"`
+	InitialVersion             string   `json:"initial_version"`
+	InitialPath                string   `json:"initial_path"`
+	MaxVersions                int      `json:"max_versions"`
+	ExcludeArchived            bool     `json:"exclude_archived"`
+	ExcludeRehydrating         bool     `json:"exclude_rehydrating"`
+	ExcludeLeased              bool     `json:"exclude_leased"`
+}
+
+// AzureConfig builds the configuration used to construct an azure.Client
+// from the source parameters.
+func (s RequestSource) AzureConfig() (azure.Config, error) {
+	environment, err := azure.EnvironmentFromName(s.Environment)
+	if err != nil {
+		return azure.Config{}, err
+	}
+
+	httpClient, err := azure.NewHTTPClient(azure.HTTPConfig{
+		ProxyURL: s.ProxyURL,
+		NoProxy:  s.NoProxy,
+		CACerts:  s.CACerts,
+	})
+	if err != nil {
+		return azure.Config{}, err
+	}
+
+	baseURL := environment.StorageEndpointSuffix
+	if s.BaseURL != "" {
+		baseURL = s.BaseURL
+	}
+
+	config := azure.Config{
+		BaseURL:                    baseURL,
+		BlobEndpoint:               s.BlobEndpoint,
+		StorageAccountName:         s.StorageAccountName,
+		StorageAccountKey:          s.StorageAccountKey,
+		SecondaryStorageAccountKey: s.SecondaryStorageAccountKey,
+		SASToken:                   s.SASToken,
+		Container:                  s.Container,
+		HTTPClient:                 httpClient,
+		ReadFromSecondary:          s.ReadFromSecondary,
+	}
+
+	if s.ConnectionString != "" {
+		if s.StorageAccountName != "" || s.StorageAccountKey != "" || s.SASToken != "" || s.BaseURL != "" || s.BlobEndpoint != "" {
+			return azure.Config{}, errors.New("connection_string cannot be combined with storage_account_name, storage_account_key, sas_token, base_url or blob_endpoint")
+		}
+
+		connectionString, err := azure.ParseConnectionString(s.ConnectionString)
+		if err != nil {
+			return azure.Config{}, err
+		}
+
+		config.StorageAccountName = connectionString.AccountName
+		config.StorageAccountKey = connectionString.AccountKey
+		config.SASToken = connectionString.SharedAccessSignature
+		config.BlobEndpoint = connectionString.BlobEndpoint
+		if connectionString.EndpointSuffix != "" {
+			config.BaseURL = connectionString.EndpointSuffix
+		}
+	}
+
+	tokenSource, err := s.tokenSource(environment, httpClient)
+	if err != nil {
+		return azure.Config{}, err
+	}
+	config.TokenSource = tokenSource
+
+	return config, nil
+}
+
+func (s RequestSource) tokenSource(environment azure.Environment, httpClient *http.Client) (azure.TokenSource, error) {
+	if s.UseWorkloadIdentity && s.UseManagedIdentity {
+		return nil, errors.New("use_workload_identity and use_managed_identity are mutually exclusive")
+	}
+
+	if s.UseWorkloadIdentity {
+		return s.workloadIdentityTokenSource(environment, httpClient)
+	}
+
+	if s.UseManagedIdentity {
+		endpoint := s.ManagedIdentityEndpoint
+		if endpoint == "" {
+			endpoint = azure.DefaultIMDSEndpoint
+		}
+
+		return azure.NewManagedIdentityTokenSource(endpoint, s.ManagedIdentityClientID)
+	}
+
+	if s.ClientID == "" {
+		return nil, nil
+	}
+
+	if s.TenantID == "" {
+		return nil, errors.New("tenant_id must be provided with client_id")
+	}
+
+	switch {
+	case s.ClientSecret != "" && s.ClientCertificate != "":
+		return nil, errors.New("client_secret and client_certificate are mutually exclusive")
+	case s.ClientSecret != "":
+		return azure.NewClientSecretTokenSource(environment.ActiveDirectoryEndpoint, s.TenantID, s.ClientID, s.ClientSecret, httpClient)
+	case s.ClientCertificate != "":
+		return azure.NewClientCertificateTokenSource(environment.ActiveDirectoryEndpoint, s.TenantID, s.ClientID, []byte(s.ClientCertificate), httpClient)
+	default:
+		return nil, errors.New("client_secret or client_certificate must be provided with client_id")
+	}
+}
+
+func (s RequestSource) workloadIdentityTokenSource(environment azure.Environment, httpClient *http.Client) (azure.TokenSource, error) {
+	tenantID := valueOrEnv(s.TenantID, "AZURE_TENANT_ID")
+	if tenantID == "" {
+		return nil, errors.New("tenant_id or AZURE_TENANT_ID must be set to use workload identity")
+	}
+
+	clientID := valueOrEnv(s.ClientID, "AZURE_CLIENT_ID")
+	if clientID == "" {
+		return nil, errors.New("client_id or AZURE_CLIENT_ID must be set to use workload identity")
+	}
+
+	tokenFile := valueOrEnv(s.FederatedTokenFile, "AZURE_FEDERATED_TOKEN_FILE")
+	if tokenFile == "" {
+		return nil, errors.New("federated_token_file or AZURE_FEDERATED_TOKEN_FILE must be set to use workload identity")
+	}
+
+	// The authority host injected by workload identity applies unless a
+	// cloud environment was chosen explicitly.
+	activeDirectoryEndpoint := environment.ActiveDirectoryEndpoint
+	if s.Environment == "" && os.Getenv("AZURE_AUTHORITY_HOST") != "" {
+		activeDirectoryEndpoint = os.Getenv("AZURE_AUTHORITY_HOST")
+	}
+
+	return azure.NewFederatedTokenSource(activeDirectoryEndpoint, tenantID, clientID, tokenFile, httpClient)
+}
+
+// RegexpOptions builds the options check applies to the versions matched by
+// regexp from the source parameters.
+func (s RequestSource) RegexpOptions() (RegexpOptions, error) {
+	scheme, err := ParseVersionScheme(s.VersionScheme)
+	if err != nil {
+		return RegexpOptions{}, err
+	}
+
+	constraint, err := ParseVersionConstraint(s.VersionConstraint, scheme)
+	if err != nil {
+		return RegexpOptions{}, err
+	}
+
+	if len(s.PrereleaseSuffixes) > 0 && !scheme.supportsPrereleaseSuffixes() {
+		return RegexpOptions{}, fmt.Errorf("prerelease_suffixes require version_scheme semi_semantic or semver: %q", scheme)
+	}
+
+	prereleases := PrereleasePolicy(s.Prereleases)
+	switch prereleases {
+	case "":
+		prereleases = PrereleasesInclude
+	case PrereleasesInclude, PrereleasesExclude, PrereleasesOnly:
+	default:
+		return RegexpOptions{}, fmt.Errorf("prereleases must be one of include, exclude or only: %q", s.Prereleases)
+	}
+
+	orderBy, err := ParseOrderBy(s.OrderBy)
+	if err != nil {
+		return RegexpOptions{}, err
+	}
+
+	if orderBy == OrderByCaptureTime && s.CaptureTimeLayout == "" {
+		return RegexpOptions{}, errors.New("capture_time_layout must be provided with order_by capture_time")
+	}
+
+	if orderBy == OrderByVersion && scheme != VersionSchemeSemiSemantic && s.Regexp != "" {
+		matcher, err := regexp.Compile(s.Regexp)
+		if err == nil && hasVersionGroups(matcher) {
+			return RegexpOptions{}, fmt.Errorf("compound version groups require version_scheme semi_semantic: %q", scheme)
+		}
+	}
+
+	initialVersion, err := s.initialVersion()
+	if err != nil {
+		return RegexpOptions{}, err
+	}
+
+	return RegexpOptions{
+		Scheme:             scheme,
+		Constraint:         constraint,
+		Prereleases:        prereleases,
+		PrereleaseSuffixes: s.PrereleaseSuffixes,
+		OrderBy:            orderBy,
+		CaptureTimeLayout:  s.CaptureTimeLayout,
+		SkipUnparsable:     s.SkipUnparsableVersions,
+		InitialVersion:     initialVersion,
+	}, nil
+}
+
+// BlobStateFilter builds the filter check applies to the state of blobs from
+// the source parameters.
+func (s RequestSource) BlobStateFilter() BlobStateFilter {
+	return BlobStateFilter{
+		ExcludeArchived:    s.ExcludeArchived,
+		ExcludeRehydrating: s.ExcludeRehydrating,
+		ExcludeLeased:      s.ExcludeLeased,
+	}
+}
+
+// initialVersion returns initial_version, or the version regexp matches in
+// initial_path.
+func (s RequestSource) initialVersion() (string, error) {
+	if s.InitialPath == "" {
+		return s.InitialVersion, nil
+	}
+
+	if s.InitialVersion != "" {
+		return "", errors.New("only one of initial_version or initial_path may be provided")
+	}
+
+	if s.Regexp == "" || OrderBy(s.OrderBy) == OrderByLastModified || s.VersionMetadataKey != "" {
+		return "", errors.New("initial_path requires a regexp that captures the version")
+	}
+
+	matcher, err := regexp.Compile(s.Regexp)
+	if err != nil {
+		return "", err
+	}
+
+	ver, ok := RegexpVersion(matcher, s.InitialPath)
+	if !ok {
+		return "", fmt.Errorf("initial_path does not match regexp: %q", s.InitialPath)
+	}
+
+	return ver, nil
+}
+
+// InitialSnapshot returns initial_version as the oldest snapshot or version id
+// time check reports for a versioned_file, or the zero time when unset. Only
+// the current version is reported with version_by etag or last_modified, so
+// initial_version is rejected there.
+func (s RequestSource) InitialSnapshot() (time.Time, error) {
+	if s.InitialVersion == "" {
+		return time.Time{}, nil
+	}
+
+	switch VersionBy(s.VersionBy) {
+	case VersionByETag, VersionByLastModified:
+		return time.Time{}, fmt.Errorf("initial_version cannot be combined with version_by %s", s.VersionBy)
+	}
+
+	snapshot, err := time.Parse(time.RFC3339Nano, s.InitialVersion)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse initial_version as a time: %s", err)
+	}
+
+	return snapshot.UTC(), nil
+}
+
+func valueOrEnv(value, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
 }
 
 type InRequestVersion struct {
-	Snapshot time.Time `json:"snapshot,omitempty"`
-	Path     string    `json:"path,omitempty"`
-	Version  string    `json:"version,omitempty"`
+	Snapshot     time.Time `json:"snapshot,omitempty"`
+	Path         string    `json:"path,omitempty"`
+	Version      string    `json:"version,omitempty"`
+	VersionID    string    `json:"version_id,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
 }
 
 type InParams struct {
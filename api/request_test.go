@@ -0,0 +1,481 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/api"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+)
+
+var _ = Describe("RequestSource", func() {
+	Describe("AzureConfig", func() {
+		It("defaults the base url to the azure public cloud", func() {
+			config, err := api.RequestSource{
+				StorageAccountName: "some-account",
+				StorageAccountKey:  "some-key",
+				Container:          "some-container",
+			}.AzureConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(azure.Config{
+				BaseURL:            "core.windows.net",
+				StorageAccountName: "some-account",
+				StorageAccountKey:  "some-key",
+				Container:          "some-container",
+			}))
+		})
+
+		It("passes through the sas token", func() {
+			config, err := api.RequestSource{
+				BaseURL:            "core.chinacloudapi.cn",
+				StorageAccountName: "some-account",
+				SASToken:           "sv=2020-02-10&sp=rl&sig=some-signature",
+				Container:          "some-container",
+			}.AzureConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(azure.Config{
+				BaseURL:            "core.chinacloudapi.cn",
+				StorageAccountName: "some-account",
+				SASToken:           "sv=2020-02-10&sp=rl&sig=some-signature",
+				Container:          "some-container",
+			}))
+		})
+
+		It("uses the storage endpoint suffix of the environment", func() {
+			config, err := api.RequestSource{
+				Environment:        "AzureUSGovernmentCloud",
+				StorageAccountName: "some-account",
+				StorageAccountKey:  "some-key",
+				Container:          "some-container",
+			}.AzureConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.BaseURL).To(Equal("core.usgovcloudapi.net"))
+		})
+
+		It("prefers the base url over the environment", func() {
+			config, err := api.RequestSource{
+				Environment: "AzureUSGovernmentCloud",
+				BaseURL:     "some.custom.suffix",
+			}.AzureConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.BaseURL).To(Equal("some.custom.suffix"))
+		})
+
+		It("returns an error for an unknown environment", func() {
+			_, err := api.RequestSource{
+				Environment: "AzureMoonCloud",
+			}.AzureConfig()
+			Expect(err).To(MatchError(ContainSubstring(`unknown environment "AzureMoonCloud"`)))
+		})
+
+		It("passes through the blob endpoint", func() {
+			config, err := api.RequestSource{
+				BlobEndpoint:       "http://127.0.0.1:10000/devstoreaccount1",
+				StorageAccountName: "devstoreaccount1",
+				StorageAccountKey:  "some-key",
+				Container:          "some-container",
+			}.AzureConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.BlobEndpoint).To(Equal("http://127.0.0.1:10000/devstoreaccount1"))
+		})
+
+		It("passes through the secondary storage account key", func() {
+			config, err := api.RequestSource{
+				StorageAccountName:         "some-account",
+				StorageAccountKey:          "some-key",
+				SecondaryStorageAccountKey: "some-other-key",
+				Container:                  "some-container",
+			}.AzureConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.StorageAccountKey).To(Equal("some-key"))
+			Expect(config.SecondaryStorageAccountKey).To(Equal("some-other-key"))
+		})
+
+		It("does not configure an http client by default", func() {
+			config, err := api.RequestSource{
+				StorageAccountName: "some-account",
+				StorageAccountKey:  "some-key",
+			}.AzureConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.HTTPClient).To(BeNil())
+		})
+
+		It("configures an http client for the proxy", func() {
+			config, err := api.RequestSource{
+				StorageAccountName: "some-account",
+				StorageAccountKey:  "some-key",
+				ProxyURL:           "http://proxy.example.com:3128",
+				NoProxy:            "localhost",
+			}.AzureConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.HTTPClient).NotTo(BeNil())
+		})
+
+		It("returns an error for invalid ca certs", func() {
+			_, err := api.RequestSource{
+				StorageAccountName: "some-account",
+				StorageAccountKey:  "some-key",
+				CACerts:            "not-a-certificate",
+			}.AzureConfig()
+			Expect(err).To(MatchError("ca certs do not contain any PEM encoded certificates"))
+		})
+
+		It("passes through reading from the secondary endpoint", func() {
+			config, err := api.RequestSource{
+				StorageAccountName: "some-account",
+				StorageAccountKey:  "some-key",
+				ReadFromSecondary:  true,
+			}.AzureConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.ReadFromSecondary).To(BeTrue())
+		})
+
+		Context("when client credentials are provided", func() {
+			It("configures a token source for a client secret", func() {
+				config, err := api.RequestSource{
+					StorageAccountName: "some-account",
+					TenantID:           "some-tenant",
+					ClientID:           "some-client-id",
+					ClientSecret:       "some-secret",
+					Container:          "some-container",
+				}.AzureConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TokenSource).NotTo(BeNil())
+			})
+
+			It("returns an error without a tenant", func() {
+				_, err := api.RequestSource{
+					ClientID:     "some-client-id",
+					ClientSecret: "some-secret",
+				}.AzureConfig()
+				Expect(err).To(MatchError("tenant_id must be provided with client_id"))
+			})
+
+			It("returns an error without a secret or certificate", func() {
+				_, err := api.RequestSource{
+					TenantID: "some-tenant",
+					ClientID: "some-client-id",
+				}.AzureConfig()
+				Expect(err).To(MatchError("client_secret or client_certificate must be provided with client_id"))
+			})
+
+			It("returns an error when both a secret and certificate are provided", func() {
+				_, err := api.RequestSource{
+					TenantID:          "some-tenant",
+					ClientID:          "some-client-id",
+					ClientSecret:      "some-secret",
+					ClientCertificate: "some-certificate",
+				}.AzureConfig()
+				Expect(err).To(MatchError("client_secret and client_certificate are mutually exclusive"))
+			})
+		})
+
+		Context("when using workload identity", func() {
+			var environment map[string]string
+
+			BeforeEach(func() {
+				environment = map[string]string{}
+				for _, key := range []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_FEDERATED_TOKEN_FILE", "AZURE_AUTHORITY_HOST"} {
+					environment[key] = os.Getenv(key)
+					os.Unsetenv(key)
+				}
+			})
+
+			AfterEach(func() {
+				for key, value := range environment {
+					os.Setenv(key, value)
+				}
+			})
+
+			It("configures a token source from the workload identity environment", func() {
+				os.Setenv("AZURE_TENANT_ID", "some-tenant")
+				os.Setenv("AZURE_CLIENT_ID", "some-client-id")
+				os.Setenv("AZURE_FEDERATED_TOKEN_FILE", "/var/run/secrets/azure/tokens/azure-identity-token")
+
+				config, err := api.RequestSource{
+					StorageAccountName:  "some-account",
+					UseWorkloadIdentity: true,
+					Container:           "some-container",
+				}.AzureConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TokenSource).NotTo(BeNil())
+			})
+
+			It("returns an error when no federated token file is configured", func() {
+				_, err := api.RequestSource{
+					TenantID:            "some-tenant",
+					ClientID:            "some-client-id",
+					UseWorkloadIdentity: true,
+				}.AzureConfig()
+				Expect(err).To(MatchError("federated_token_file or AZURE_FEDERATED_TOKEN_FILE must be set to use workload identity"))
+			})
+
+			It("returns an error when no client id is configured", func() {
+				_, err := api.RequestSource{
+					TenantID:            "some-tenant",
+					FederatedTokenFile:  "/some/token",
+					UseWorkloadIdentity: true,
+				}.AzureConfig()
+				Expect(err).To(MatchError("client_id or AZURE_CLIENT_ID must be set to use workload identity"))
+			})
+		})
+
+		Context("when using a managed identity", func() {
+			It("configures a token source", func() {
+				config, err := api.RequestSource{
+					StorageAccountName:      "some-account",
+					UseManagedIdentity:      true,
+					ManagedIdentityClientID: "some-identity-client-id",
+					Container:               "some-container",
+				}.AzureConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TokenSource).NotTo(BeNil())
+			})
+
+			It("never sends token requests through a proxy from the environment", func() {
+				var proxied []*http.Request
+				proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					proxied = append(proxied, r)
+					w.WriteHeader(http.StatusBadGateway)
+				}))
+				defer proxy.Close()
+
+				httpProxy := os.Getenv("HTTP_PROXY")
+				os.Setenv("HTTP_PROXY", proxy.URL)
+				defer os.Setenv("HTTP_PROXY", httpProxy)
+
+				config, err := api.RequestSource{
+					StorageAccountName:      "some-account",
+					UseManagedIdentity:      true,
+					ManagedIdentityEndpoint: "http://imds.invalid/metadata/identity/oauth2/token",
+					Container:               "some-container",
+				}.AzureConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.HTTPClient).To(BeNil())
+
+				_, err = config.TokenSource.Token()
+				Expect(err).To(HaveOccurred())
+				Expect(proxied).To(BeEmpty())
+			})
+
+			It("returns an error when workload identity is also enabled", func() {
+				_, err := api.RequestSource{
+					UseManagedIdentity:  true,
+					UseWorkloadIdentity: true,
+				}.AzureConfig()
+				Expect(err).To(MatchError("use_workload_identity and use_managed_identity are mutually exclusive"))
+			})
+		})
+
+		Context("when a connection string is provided", func() {
+			It("configures the account from the connection string", func() {
+				config, err := api.RequestSource{
+					ConnectionString: "DefaultEndpointsProtocol=https;AccountName=someaccount;AccountKey=c29tZS1rZXk=;EndpointSuffix=core.usgovcloudapi.net",
+					Container:        "some-container",
+				}.AzureConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config).To(Equal(azure.Config{
+					BaseURL:            "core.usgovcloudapi.net",
+					StorageAccountName: "someaccount",
+					StorageAccountKey:  "c29tZS1rZXk=",
+					Container:          "some-container",
+				}))
+			})
+
+			It("configures the blob endpoint and sas token from the connection string", func() {
+				config, err := api.RequestSource{
+					ConnectionString: "BlobEndpoint=https://someaccount.blob.core.windows.net;SharedAccessSignature=sv=2020-02-10&sp=rl&sig=some-signature",
+					Container:        "some-container",
+				}.AzureConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config).To(Equal(azure.Config{
+					BaseURL:      "core.windows.net",
+					BlobEndpoint: "https://someaccount.blob.core.windows.net",
+					SASToken:     "sv=2020-02-10&sp=rl&sig=some-signature",
+					Container:    "some-container",
+				}))
+			})
+
+			It("returns an error when combined with account fields", func() {
+				_, err := api.RequestSource{
+					ConnectionString:   "AccountName=someaccount;AccountKey=c29tZS1rZXk=",
+					StorageAccountName: "someaccount",
+				}.AzureConfig()
+				Expect(err).To(MatchError("connection_string cannot be combined with storage_account_name, storage_account_key, sas_token, base_url or blob_endpoint"))
+			})
+		})
+	})
+
+	Describe("InitialSnapshot", func() {
+		It("returns the zero time without an initial version", func() {
+			snapshot, err := api.RequestSource{}.InitialSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot.IsZero()).To(BeTrue())
+		})
+
+		It("parses the initial version as a time", func() {
+			snapshot, err := api.RequestSource{InitialVersion: "2017-01-02T03:04:05.6Z"}.InitialSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot).To(Equal(time.Date(2017, 1, 2, 3, 4, 5, 600000*1000, time.UTC)))
+		})
+
+		It("returns an error for an initial version that is not a time", func() {
+			_, err := api.RequestSource{InitialVersion: "1.2.0"}.InitialSnapshot()
+			Expect(err).To(MatchError(ContainSubstring("failed to parse initial_version as a time")))
+		})
+
+		It("returns an error with version_by etag or last_modified", func() {
+			_, err := api.RequestSource{InitialVersion: "2017-01-02T03:04:05Z", VersionBy: "etag"}.InitialSnapshot()
+			Expect(err).To(MatchError("initial_version cannot be combined with version_by etag"))
+
+			_, err = api.RequestSource{InitialVersion: "2017-01-02T03:04:05Z", VersionBy: "last_modified"}.InitialSnapshot()
+			Expect(err).To(MatchError("initial_version cannot be combined with version_by last_modified"))
+		})
+	})
+
+	Describe("RegexpOptions", func() {
+		It("includes prereleases by default", func() {
+			options, err := api.RequestSource{}.RegexpOptions()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(options.Prereleases).To(Equal(api.PrereleasesInclude))
+		})
+
+		It("passes through the prerelease options", func() {
+			options, err := api.RequestSource{
+				Prereleases:        "exclude",
+				PrereleaseSuffixes: []string{"-dev", "-build."},
+			}.RegexpOptions()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(options.Prereleases).To(Equal(api.PrereleasesExclude))
+			Expect(options.PrereleaseSuffixes).To(Equal([]string{"-dev", "-build."}))
+		})
+
+		It("returns an error for prerelease suffixes with a scheme that does not support them", func() {
+			for _, scheme := range []string{"numeric", "lexical", "calver", "debian"} {
+				_, err := api.RequestSource{
+					VersionScheme:      scheme,
+					PrereleaseSuffixes: []string{"-dev"},
+				}.RegexpOptions()
+				Expect(err).To(MatchError(fmt.Sprintf("prerelease_suffixes require version_scheme semi_semantic or semver: %q", scheme)))
+			}
+
+			_, err := api.RequestSource{
+				VersionScheme:      "semver",
+				PrereleaseSuffixes: []string{"-dev"},
+			}.RegexpOptions()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns an error for an unknown prerelease policy", func() {
+			_, err := api.RequestSource{Prereleases: "sometimes"}.RegexpOptions()
+			Expect(err).To(MatchError(`prereleases must be one of include, exclude or only: "sometimes"`))
+		})
+
+		It("returns an error for an invalid version constraint", func() {
+			_, err := api.RequestSource{VersionConstraint: ">="}.RegexpOptions()
+			Expect(err).To(MatchError(ContainSubstring("failed to parse version constraint")))
+		})
+
+		It("passes through the ordering options", func() {
+			options, err := api.RequestSource{
+				OrderBy:                "capture_time",
+				CaptureTimeLayout:      "2006-01-02T1504",
+				SkipUnparsableVersions: true,
+			}.RegexpOptions()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(options.OrderBy).To(Equal(api.OrderByCaptureTime))
+			Expect(options.CaptureTimeLayout).To(Equal("2006-01-02T1504"))
+			Expect(options.SkipUnparsable).To(BeTrue())
+		})
+
+		It("parses the version constraint with the version scheme", func() {
+			options, err := api.RequestSource{
+				VersionScheme:     "numeric",
+				VersionConstraint: "<10",
+			}.RegexpOptions()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(options.Scheme).To(Equal(api.VersionSchemeNumeric))
+			Expect(options.Constraint.Allows("9")).To(BeTrue())
+
+			_, err = api.RequestSource{
+				VersionScheme:     "numeric",
+				VersionConstraint: "<1.0",
+			}.RegexpOptions()
+			Expect(err).To(MatchError(ContainSubstring("failed to parse version constraint")))
+		})
+
+		It("returns an error for compound version groups with a scheme other than semi_semantic", func() {
+			expr := `app_(?P<major>\d+)\.(?P<minor>\d+)_b(?P<build>\d+)\.tgz`
+
+			_, err := api.RequestSource{Regexp: expr, VersionScheme: "numeric"}.RegexpOptions()
+			Expect(err).To(MatchError(`compound version groups require version_scheme semi_semantic: "numeric"`))
+
+			_, err = api.RequestSource{Regexp: expr, VersionScheme: "semver"}.RegexpOptions()
+			Expect(err).To(HaveOccurred())
+
+			_, err = api.RequestSource{Regexp: expr}.RegexpOptions()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = api.RequestSource{Regexp: expr, VersionScheme: "numeric", OrderBy: "last_modified"}.RegexpOptions()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns an error for an unknown version scheme", func() {
+			_, err := api.RequestSource{VersionScheme: "roman"}.RegexpOptions()
+			Expect(err).To(MatchError(ContainSubstring("version_scheme must be one of")))
+		})
+
+		Context("with an initial version", func() {
+			It("passes through initial_version", func() {
+				options, err := api.RequestSource{InitialVersion: "1.2.0"}.RegexpOptions()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.InitialVersion).To(Equal("1.2.0"))
+			})
+
+			It("takes the version from initial_path", func() {
+				options, err := api.RequestSource{
+					Regexp:      `releases/product-(.*)\.tgz`,
+					InitialPath: "releases/product-1.2.0.tgz",
+				}.RegexpOptions()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.InitialVersion).To(Equal("1.2.0"))
+			})
+
+			It("returns an error when initial_path does not match the regexp", func() {
+				_, err := api.RequestSource{
+					Regexp:      `releases/product-(.*)\.tgz`,
+					InitialPath: "product-1.2.0.zip",
+				}.RegexpOptions()
+				Expect(err).To(MatchError(`initial_path does not match regexp: "product-1.2.0.zip"`))
+			})
+
+			It("returns an error when both are provided", func() {
+				_, err := api.RequestSource{
+					Regexp:         `releases/product-(.*)\.tgz`,
+					InitialVersion: "1.2.0",
+					InitialPath:    "releases/product-1.2.0.tgz",
+				}.RegexpOptions()
+				Expect(err).To(MatchError("only one of initial_version or initial_path may be provided"))
+			})
+
+			It("returns an error for initial_path without a regexp capturing the version", func() {
+				_, err := api.RequestSource{
+					Regexp:      `releases/.*\.tgz`,
+					OrderBy:     "last_modified",
+					InitialPath: "releases/product-1.2.0.tgz",
+				}.RegexpOptions()
+				Expect(err).To(MatchError("initial_path requires a regexp that captures the version"))
+			})
+		})
+
+		It("returns an error when ordering by capture time without a layout", func() {
+			_, err := api.RequestSource{OrderBy: "capture_time"}.RegexpOptions()
+			Expect(err).To(MatchError("capture_time_layout must be provided with order_by capture_time"))
+		})
+	})
+})
@@ -8,9 +8,12 @@ type Response struct {
 }
 
 type ResponseVersion struct {
-	Snapshot *time.Time `json:"snapshot,omitempty"`
-	Path     string     `json:"path,omitempty"`
-	Version  string     `json:"version,omitempty"`
+	Snapshot     *time.Time `json:"snapshot,omitempty"`
+	Path         string     `json:"path,omitempty"`
+	Version      string     `json:"version,omitempty"`
+	VersionID    string     `json:"version_id,omitempty"`
+	ETag         string     `json:"etag,omitempty"`
+	LastModified *time.Time `json:"last_modified,omitempty"`
 }
 
 type ResponseMetadata struct {
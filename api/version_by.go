@@ -0,0 +1,26 @@
+package api
+
+import "fmt"
+
+// VersionBy decides how check identifies the versions of a versioned_file.
+type VersionBy string
+
+const (
+	VersionBySnapshot     VersionBy = "snapshot"
+	VersionByETag         VersionBy = "etag"
+	VersionByLastModified VersionBy = "last_modified"
+	VersionByVersionID    VersionBy = "version_id"
+)
+
+// ParseVersionBy parses the version_by source parameter, which defaults to
+// snapshots.
+func ParseVersionBy(versionBy string) (VersionBy, error) {
+	switch VersionBy(versionBy) {
+	case "":
+		return VersionBySnapshot, nil
+	case VersionBySnapshot, VersionByETag, VersionByLastModified, VersionByVersionID:
+		return VersionBy(versionBy), nil
+	default:
+		return "", fmt.Errorf("version_by must be one of snapshot, etag, last_modified or version_id: %q", versionBy)
+	}
+}
@@ -0,0 +1,30 @@
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/api"
+)
+
+var _ = Describe("ParseVersionBy", func() {
+	It("defaults to snapshots", func() {
+		versionBy, err := api.ParseVersionBy("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(versionBy).To(Equal(api.VersionBySnapshot))
+	})
+
+	It("accepts etags, last modified times and version ids", func() {
+		versionBy, err := api.ParseVersionBy("etag")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(versionBy).To(Equal(api.VersionByETag))
+
+		versionBy, err = api.ParseVersionBy("last_modified")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(versionBy).To(Equal(api.VersionByLastModified))
+	})
+
+	It("returns an error for an unknown value", func() {
+		_, err := api.ParseVersionBy("content_md5")
+		Expect(err).To(MatchError(`version_by must be one of snapshot, etag, last_modified or version_id: "content_md5"`))
+	})
+})
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// calverPattern matches a two or four digit year followed by any number of
+// dot separated numbers, such as "24.04" or "2024.05.1", and an optional
+// modifier, such as "2024.05.1-rc.1".
+var calverPattern = regexp.MustCompile(`^(\d{2}|\d{4})((?:\.\d+)*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// calverVersion is a calendar version. A version with a modifier precedes the
+// same version without one.
+type calverVersion struct {
+	raw      string
+	segments []string
+	modifier []string
+}
+
+func parseCalver(ver string) (schemeVersion, error) {
+	matches := calverPattern.FindStringSubmatch(ver)
+	if matches == nil {
+		return nil, fmt.Errorf("expected version %q to be a calendar version", ver)
+	}
+
+	parsed := calverVersion{
+		raw:      ver,
+		segments: []string{matches[1]},
+	}
+	if matches[2] != "" {
+		parsed.segments = append(parsed.segments, strings.Split(matches[2][1:], ".")...)
+	}
+	if matches[3] != "" {
+		parsed.modifier = strings.Split(matches[3], ".")
+	}
+
+	return parsed, nil
+}
+
+func (v calverVersion) compare(other schemeVersion) int {
+	o := other.(calverVersion)
+
+	// Missing segments count as zero, so "2024.05" equals "2024.05.0".
+	for i := 0; i < len(v.segments) || i < len(o.segments); i++ {
+		a, b := "0", "0"
+		if i < len(v.segments) {
+			a = v.segments[i]
+		}
+		if i < len(o.segments) {
+			b = o.segments[i]
+		}
+
+		result := compareDigits(a, b)
+		if result != 0 {
+			return result
+		}
+	}
+
+	switch {
+	case len(v.modifier) == 0 && len(o.modifier) == 0:
+		return 0
+	case len(v.modifier) == 0:
+		return 1
+	case len(o.modifier) == 0:
+		return -1
+	default:
+		return compareIdentifiers(v.modifier, o.modifier)
+	}
+}
+
+func (v calverVersion) isPreRelease() bool {
+	return len(v.modifier) > 0
+}
+
+func (v calverVersion) String() string {
+	return v.raw
+}
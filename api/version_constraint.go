@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+var constraintOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+type versionComparison struct {
+	operator string
+	version  schemeVersion
+}
+
+// VersionConstraint restricts the versions reported by check. The zero value
+// allows every version.
+type VersionConstraint struct {
+	scheme      VersionScheme
+	comparisons []versionComparison
+}
+
+// ParseVersionConstraint parses a comma separated list of comparisons such as
+// ">=2.3, <3.0", all of which a version must satisfy. A comparison without an
+// operator requires an equal version. Versions are parsed with scheme.
+func ParseVersionConstraint(constraint string, scheme VersionScheme) (VersionConstraint, error) {
+	if strings.TrimSpace(constraint) == "" {
+		return VersionConstraint{}, nil
+	}
+
+	var comparisons []versionComparison
+	for _, term := range strings.Split(constraint, ",") {
+		term = strings.TrimSpace(term)
+
+		operator := "="
+		for _, candidate := range constraintOperators {
+			if strings.HasPrefix(term, candidate) {
+				operator = candidate
+				term = strings.TrimSpace(strings.TrimPrefix(term, candidate))
+				break
+			}
+		}
+
+		ver, err := scheme.parse(term)
+		if err != nil {
+			return VersionConstraint{}, fmt.Errorf("failed to parse version constraint %q: %s", constraint, err)
+		}
+
+		comparisons = append(comparisons, versionComparison{operator: operator, version: ver})
+	}
+
+	return VersionConstraint{scheme: scheme, comparisons: comparisons}, nil
+}
+
+// Allows reports whether ver satisfies every comparison of the constraint. A
+// version that cannot be parsed is not allowed by a non-empty constraint.
+func (c VersionConstraint) Allows(ver string) bool {
+	if len(c.comparisons) == 0 {
+		return true
+	}
+
+	parsed, err := c.scheme.parse(ver)
+	if err != nil {
+		return false
+	}
+
+	return c.allows(parsed)
+}
+
+func (c VersionConstraint) allows(ver schemeVersion) bool {
+	for _, comparison := range c.comparisons {
+		result := ver.compare(comparison.version)
+
+		var ok bool
+		switch comparison.operator {
+		case ">=":
+			ok = result >= 0
+		case "<=":
+			ok = result <= 0
+		case "!=":
+			ok = result != 0
+		case ">":
+			ok = result > 0
+		case "<":
+			ok = result < 0
+		default:
+			ok = result == 0
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,34 @@
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/api"
+)
+
+var _ = Describe("VersionConstraint", func() {
+	DescribeTable("Allows",
+		func(constraint, ver string, allowed bool) {
+			versionConstraint, err := api.ParseVersionConstraint(constraint, api.VersionSchemeSemiSemantic)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionConstraint.Allows(ver)).To(Equal(allowed))
+		},
+		Entry("no constraint", "", "1.2.3", true),
+		Entry("equal without operator", "1.2", "1.2.0", true),
+		Entry("equal", "=1.2.3", "1.2.4", false),
+		Entry("not equal", "!= 1.2.3", "1.2.3", false),
+		Entry("greater than", ">1.2.3", "1.2.4", true),
+		Entry("greater than or equal", ">=1.2.3", "1.2.3", true),
+		Entry("less than", "<2.0", "2.0.0", false),
+		Entry("less than or equal", "<=2.0", "2.0.0", true),
+		Entry("within a range", ">=2.3, <3.0", "2.9.1", true),
+		Entry("below a range", ">=2.3, <3.0", "2.2.9", false),
+		Entry("above a range", ">=2.3, <3.0", "3.0.0", false),
+	)
+
+	It("returns an error for an invalid version", func() {
+		_, err := api.ParseVersionConstraint(">=2.3, <", api.VersionSchemeSemiSemantic)
+		Expect(err).To(MatchError(ContainSubstring(`failed to parse version constraint ">=2.3, <"`)))
+	})
+})
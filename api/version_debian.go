@@ -0,0 +1,143 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	debianUpstreamPattern = regexp.MustCompile(`^\d[A-Za-z0-9.+~:-]*$`)
+	debianRevisionPattern = regexp.MustCompile(`^[A-Za-z0-9.+~]+$`)
+)
+
+// debianVersion is a Debian package version, "[epoch:]upstream[-revision]",
+// compared as dpkg does.
+type debianVersion struct {
+	raw      string
+	epoch    string
+	upstream string
+	revision string
+}
+
+func parseDebian(ver string) (schemeVersion, error) {
+	parsed := debianVersion{raw: ver, epoch: "0", upstream: ver}
+
+	if i := strings.Index(parsed.upstream, ":"); i >= 0 {
+		parsed.epoch = parsed.upstream[:i]
+		parsed.upstream = parsed.upstream[i+1:]
+
+		if !numericPattern.MatchString(parsed.epoch) {
+			return nil, fmt.Errorf("expected epoch of debian version %q to be a non-negative integer", ver)
+		}
+	}
+
+	if i := strings.LastIndex(parsed.upstream, "-"); i >= 0 {
+		parsed.revision = parsed.upstream[i+1:]
+		parsed.upstream = parsed.upstream[:i]
+
+		if !debianRevisionPattern.MatchString(parsed.revision) {
+			return nil, fmt.Errorf("expected revision of debian version %q to contain only alphanumerics and +.~", ver)
+		}
+	}
+
+	if !debianUpstreamPattern.MatchString(parsed.upstream) {
+		return nil, fmt.Errorf("expected upstream version of debian version %q to start with a digit and contain only alphanumerics and .+~-:", ver)
+	}
+
+	return parsed, nil
+}
+
+func (v debianVersion) compare(other schemeVersion) int {
+	o := other.(debianVersion)
+
+	result := compareDigits(v.epoch, o.epoch)
+	if result != 0 {
+		return result
+	}
+
+	result = compareDebianParts(v.upstream, o.upstream)
+	if result != 0 {
+		return result
+	}
+
+	return compareDebianParts(v.revision, o.revision)
+}
+
+func (v debianVersion) isPreRelease() bool {
+	return strings.Contains(v.upstream, "~")
+}
+
+func (v debianVersion) String() string {
+	return v.raw
+}
+
+// compareDebianParts compares upstream versions or revisions with the
+// algorithm of dpkg: alternating runs of non-digits, compared character by
+// character with letters before other characters and "~" before anything,
+// even the end of the string, and runs of digits, compared by value.
+func compareDebianParts(a, b string) int {
+	for a != "" || b != "" {
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			ac, bc := debianOrder(a), debianOrder(b)
+			if ac != bc {
+				return sign(ac - bc)
+			}
+
+			a, b = a[1:], b[1:]
+		}
+
+		aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+		result := compareDigits(aDigits, bDigits)
+		if result != 0 {
+			return result
+		}
+
+		a, b = a[len(aDigits):], b[len(bDigits):]
+	}
+
+	return 0
+}
+
+// debianOrder returns the weight of the first character of s, which is zero
+// at the end of s and for digits.
+func debianOrder(s string) int {
+	if s == "" {
+		return 0
+	}
+
+	c := s[0]
+	switch {
+	case isDigit(c):
+		return 0
+	case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cppforlife/go-semi-semantic/version"
+)
+
+// VersionScheme decides how the versions matched by a regexp are parsed and
+// compared.
+type VersionScheme string
+
+const (
+	VersionSchemeSemver       VersionScheme = "semver"
+	VersionSchemeSemiSemantic VersionScheme = "semi_semantic"
+	VersionSchemeNumeric      VersionScheme = "numeric"
+	VersionSchemeLexical      VersionScheme = "lexical"
+	VersionSchemeCalver       VersionScheme = "calver"
+	VersionSchemeDebian       VersionScheme = "debian"
+)
+
+// ParseVersionScheme parses the version_scheme source parameter, which
+// defaults to semi-semantic versions.
+func ParseVersionScheme(scheme string) (VersionScheme, error) {
+	switch VersionScheme(scheme) {
+	case "":
+		return VersionSchemeSemiSemantic, nil
+	case VersionSchemeSemver, VersionSchemeSemiSemantic, VersionSchemeNumeric,
+		VersionSchemeLexical, VersionSchemeCalver, VersionSchemeDebian:
+		return VersionScheme(scheme), nil
+	default:
+		return "", fmt.Errorf("version_scheme must be one of semver, semi_semantic, numeric, lexical, calver or debian: %q", scheme)
+	}
+}
+
+// FormatVersion returns ver as it is reported in the version of a blob, or an
+// error if ver is not valid in the scheme.
+func (s VersionScheme) FormatVersion(ver string) (string, error) {
+	parsed, err := s.parse(ver)
+	if err != nil {
+		return "", err
+	}
+
+	return parsed.String(), nil
+}
+
+// schemeVersion is a version parsed by a VersionScheme. Versions are only
+// compared with versions of the same scheme.
+type schemeVersion interface {
+	compare(other schemeVersion) int
+	isPreRelease() bool
+	String() string
+}
+
+func (s VersionScheme) parse(ver string) (schemeVersion, error) {
+	switch s {
+	case VersionSchemeSemver:
+		return parseSemver(ver)
+	case VersionSchemeNumeric:
+		return parseNumeric(ver)
+	case VersionSchemeLexical:
+		return lexicalVersion(ver), nil
+	case VersionSchemeCalver:
+		return parseCalver(ver)
+	case VersionSchemeDebian:
+		return parseDebian(ver)
+	default:
+		return parseSemiSemantic(ver)
+	}
+}
+
+// supportsPrereleaseSuffixes reports whether prerelease_suffixes can be moved
+// into the prerelease segment of the scheme's versions.
+func (s VersionScheme) supportsPrereleaseSuffixes() bool {
+	switch s {
+	case "", VersionSchemeSemiSemantic, VersionSchemeSemver:
+		return true
+	default:
+		return false
+	}
+}
+
+type semiSemanticVersion struct {
+	version.Version
+}
+
+func parseSemiSemantic(ver string) (schemeVersion, error) {
+	parsed, err := version.NewVersionFromString(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return semiSemanticVersion{parsed}, nil
+}
+
+func (v semiSemanticVersion) compare(other schemeVersion) int {
+	return v.Version.Compare(other.(semiSemanticVersion).Version)
+}
+
+func (v semiSemanticVersion) isPreRelease() bool {
+	return !v.PreRelease.Empty()
+}
+
+func (v semiSemanticVersion) String() string {
+	return v.AsString()
+}
+
+var numericPattern = regexp.MustCompile(`^\d+$`)
+
+// numericVersion is a non-negative integer of any length.
+type numericVersion string
+
+func parseNumeric(ver string) (schemeVersion, error) {
+	if !numericPattern.MatchString(ver) {
+		return nil, fmt.Errorf("expected version %q to be a non-negative integer", ver)
+	}
+
+	return numericVersion(ver), nil
+}
+
+func (v numericVersion) compare(other schemeVersion) int {
+	return compareDigits(string(v), string(other.(numericVersion)))
+}
+
+func (v numericVersion) isPreRelease() bool {
+	return false
+}
+
+func (v numericVersion) String() string {
+	return string(v)
+}
+
+// lexicalVersion orders versions as plain strings.
+type lexicalVersion string
+
+func (v lexicalVersion) compare(other schemeVersion) int {
+	return strings.Compare(string(v), string(other.(lexicalVersion)))
+}
+
+func (v lexicalVersion) isPreRelease() bool {
+	return false
+}
+
+func (v lexicalVersion) String() string {
+	return string(v)
+}
+
+// compareDigits compares two strings of decimal digits by their value.
+func compareDigits(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+// compareIdentifiers compares dot separated prerelease identifiers as
+// semantic versioning does: numeric identifiers by value and before
+// alphanumeric ones, which compare in ASCII order, and a shorter list of
+// otherwise equal identifiers first.
+func compareIdentifiers(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		aNumeric := numericPattern.MatchString(a[i])
+		bNumeric := numericPattern.MatchString(b[i])
+
+		var result int
+		switch {
+		case aNumeric && bNumeric:
+			result = compareDigits(a[i], b[i])
+		case aNumeric:
+			result = -1
+		case bNumeric:
+			result = 1
+		default:
+			result = strings.Compare(a[i], b[i])
+		}
+
+		if result != 0 {
+			return result
+		}
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,72 @@
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/api"
+)
+
+var _ = Describe("VersionScheme", func() {
+	Describe("ParseVersionScheme", func() {
+		It("defaults to semi-semantic versions", func() {
+			scheme, err := api.ParseVersionScheme("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(scheme).To(Equal(api.VersionSchemeSemiSemantic))
+		})
+
+		It("returns an error for an unknown value", func() {
+			_, err := api.ParseVersionScheme("roman")
+			Expect(err).To(MatchError(`version_scheme must be one of semver, semi_semantic, numeric, lexical, calver or debian: "roman"`))
+		})
+	})
+
+	DescribeTable("ordering",
+		func(scheme api.VersionScheme, lower, higher string) {
+			lessThanHigher, err := api.ParseVersionConstraint("<"+higher, scheme)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lessThanHigher.Allows(lower)).To(BeTrue())
+
+			lessThanLower, err := api.ParseVersionConstraint("<"+lower, scheme)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lessThanLower.Allows(higher)).To(BeFalse())
+		},
+		Entry("semver release", api.VersionSchemeSemver, "1.9.0", "1.10.0"),
+		Entry("semver prerelease", api.VersionSchemeSemver, "1.0.0-rc.1", "1.0.0"),
+		Entry("semver numeric prerelease identifiers", api.VersionSchemeSemver, "1.0.0-rc.2", "1.0.0-rc.10"),
+		Entry("semver alphanumeric prerelease identifiers", api.VersionSchemeSemver, "1.0.0-alpha", "1.0.0-beta"),
+		Entry("semver longer prerelease", api.VersionSchemeSemver, "1.0.0-alpha", "1.0.0-alpha.1"),
+		Entry("semi-semantic", api.VersionSchemeSemiSemantic, "1.9", "1.10.0"),
+		Entry("numeric", api.VersionSchemeNumeric, "9", "0010"),
+		Entry("lexical", api.VersionSchemeLexical, "10", "9"),
+		Entry("calver", api.VersionSchemeCalver, "2024.9.1", "2024.10"),
+		Entry("calver modifier", api.VersionSchemeCalver, "24.04-rc.1", "24.04"),
+		Entry("debian", api.VersionSchemeDebian, "1.2.3-1", "1.2.10-1"),
+		Entry("debian tilde", api.VersionSchemeDebian, "1.0~rc1-1", "1.0-1"),
+		Entry("debian letters before symbols", api.VersionSchemeDebian, "1.0a", "1.0+"),
+		Entry("debian revision", api.VersionSchemeDebian, "1.0-1ubuntu1", "1.0-2"),
+		Entry("debian epoch", api.VersionSchemeDebian, "2.0-1", "1:1.0-1"),
+	)
+
+	DescribeTable("invalid versions",
+		func(scheme api.VersionScheme, ver string) {
+			_, err := scheme.FormatVersion(ver)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("semver without patch", api.VersionSchemeSemver, "1.2"),
+		Entry("semver with leading zeros", api.VersionSchemeSemver, "01.2.3"),
+		Entry("semver with a prefix", api.VersionSchemeSemver, "v1.2.3"),
+		Entry("numeric with a dot", api.VersionSchemeNumeric, "1.2"),
+		Entry("calver without a year", api.VersionSchemeCalver, "123.4"),
+		Entry("debian without a leading digit", api.VersionSchemeDebian, "v1.0"),
+		Entry("debian with an invalid epoch", api.VersionSchemeDebian, "a:1.0"),
+	)
+
+	Describe("FormatVersion", func() {
+		It("keeps versions of other schemes as they are", func() {
+			ver, err := api.VersionSchemeSemver.FormatVersion("1.2.0+build.7")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ver).To(Equal("1.2.0+build.7"))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// semverPattern is the pattern suggested by the Semantic Versioning 2.0.0
+// specification.
+var semverPattern = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// semverVersion is a strict semantic version. Build metadata is kept in the
+// reported version but ignored when comparing.
+type semverVersion struct {
+	raw        string
+	release    [3]string
+	preRelease []string
+}
+
+func parseSemver(ver string) (schemeVersion, error) {
+	matches := semverPattern.FindStringSubmatch(ver)
+	if matches == nil {
+		return nil, fmt.Errorf("expected version %q to be a semantic version", ver)
+	}
+
+	parsed := semverVersion{
+		raw:     ver,
+		release: [3]string{matches[1], matches[2], matches[3]},
+	}
+	if matches[4] != "" {
+		parsed.preRelease = strings.Split(matches[4], ".")
+	}
+
+	return parsed, nil
+}
+
+func (v semverVersion) compare(other schemeVersion) int {
+	o := other.(semverVersion)
+
+	for i := range v.release {
+		result := compareDigits(v.release[i], o.release[i])
+		if result != 0 {
+			return result
+		}
+	}
+
+	// A version without prerelease identifiers follows its prereleases.
+	switch {
+	case len(v.preRelease) == 0 && len(o.preRelease) == 0:
+		return 0
+	case len(v.preRelease) == 0:
+		return 1
+	case len(o.preRelease) == 0:
+		return -1
+	default:
+		return compareIdentifiers(v.preRelease, o.preRelease)
+	}
+}
+
+func (v semverVersion) isPreRelease() bool {
+	return len(v.preRelease) > 0
+}
+
+func (v semverVersion) String() string {
+	return v.raw
+}
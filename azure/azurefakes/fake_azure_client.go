@@ -40,6 +40,50 @@ type FakeAzureClient struct {
 	downloadBlobToFileReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DownloadBlobToFileIfUnchangedStub        func(string, *os.File, string, time.Time, int64, time.Duration) error
+	downloadBlobToFileIfUnchangedMutex       sync.RWMutex
+	downloadBlobToFileIfUnchangedArgsForCall []struct {
+		arg1 string
+		arg2 *os.File
+		arg3 string
+		arg4 time.Time
+		arg5 int64
+		arg6 time.Duration
+	}
+	downloadBlobToFileIfUnchangedReturns struct {
+		result1 error
+	}
+	downloadBlobToFileIfUnchangedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DownloadBlobVersionToFileStub        func(string, string, *os.File, int64, time.Duration) error
+	downloadBlobVersionToFileMutex       sync.RWMutex
+	downloadBlobVersionToFileArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 *os.File
+		arg4 int64
+		arg5 time.Duration
+	}
+	downloadBlobVersionToFileReturns struct {
+		result1 error
+	}
+	downloadBlobVersionToFileReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FindBlobsByTagsStub        func(string) ([]azure.TaggedBlob, error)
+	findBlobsByTagsMutex       sync.RWMutex
+	findBlobsByTagsArgsForCall []struct {
+		arg1 string
+	}
+	findBlobsByTagsReturns struct {
+		result1 []azure.TaggedBlob
+		result2 error
+	}
+	findBlobsByTagsReturnsOnCall map[int]struct {
+		result1 []azure.TaggedBlob
+		result2 error
+	}
 	GetStub        func(string, time.Time) ([]byte, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
@@ -68,6 +112,32 @@ type FakeAzureClient struct {
 		result1 int64
 		result2 error
 	}
+	GetBlobStateStub        func(string) (azure.BlobState, error)
+	getBlobStateMutex       sync.RWMutex
+	getBlobStateArgsForCall []struct {
+		arg1 string
+	}
+	getBlobStateReturns struct {
+		result1 azure.BlobState
+		result2 error
+	}
+	getBlobStateReturnsOnCall map[int]struct {
+		result1 azure.BlobState
+		result2 error
+	}
+	GetBlobTagsStub        func(string) (map[string]string, error)
+	getBlobTagsMutex       sync.RWMutex
+	getBlobTagsArgsForCall []struct {
+		arg1 string
+	}
+	getBlobTagsReturns struct {
+		result1 map[string]string
+		result2 error
+	}
+	getBlobTagsReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 error
+	}
 	GetBlobURLStub        func(string) (string, error)
 	getBlobURLMutex       sync.RWMutex
 	getBlobURLArgsForCall []struct {
@@ -81,6 +151,19 @@ type FakeAzureClient struct {
 		result1 string
 		result2 error
 	}
+	ListBlobVersionsStub        func(string) ([]azure.BlobVersion, error)
+	listBlobVersionsMutex       sync.RWMutex
+	listBlobVersionsArgsForCall []struct {
+		arg1 string
+	}
+	listBlobVersionsReturns struct {
+		result1 []azure.BlobVersion
+		result2 error
+	}
+	listBlobVersionsReturnsOnCall map[int]struct {
+		result1 []azure.BlobVersion
+		result2 error
+	}
 	ListBlobsStub        func(storage.ListBlobsParameters) (storage.BlobListResponse, error)
 	listBlobsMutex       sync.RWMutex
 	listBlobsArgsForCall []struct {
@@ -94,6 +177,55 @@ type FakeAzureClient struct {
 		result1 storage.BlobListResponse
 		result2 error
 	}
+	ListBlobsWithStatesStub        func(storage.ListBlobsParameters) (storage.BlobListResponse, []azure.BlobState, error)
+	listBlobsWithStatesMutex       sync.RWMutex
+	listBlobsWithStatesArgsForCall []struct {
+		arg1 storage.ListBlobsParameters
+	}
+	listBlobsWithStatesReturns struct {
+		result1 storage.BlobListResponse
+		result2 []azure.BlobState
+		result3 error
+	}
+	listBlobsWithStatesReturnsOnCall map[int]struct {
+		result1 storage.BlobListResponse
+		result2 []azure.BlobState
+		result3 error
+	}
+	UploadBlobETagFromStreamStub        func(string, io.Reader, int, time.Duration) (string, time.Time, error)
+	uploadBlobETagFromStreamMutex       sync.RWMutex
+	uploadBlobETagFromStreamArgsForCall []struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 int
+		arg4 time.Duration
+	}
+	uploadBlobETagFromStreamReturns struct {
+		result1 string
+		result2 time.Time
+		result3 error
+	}
+	uploadBlobETagFromStreamReturnsOnCall map[int]struct {
+		result1 string
+		result2 time.Time
+		result3 error
+	}
+	UploadBlobVersionFromStreamStub        func(string, io.Reader, int, time.Duration) (string, error)
+	uploadBlobVersionFromStreamMutex       sync.RWMutex
+	uploadBlobVersionFromStreamArgsForCall []struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 int
+		arg4 time.Duration
+	}
+	uploadBlobVersionFromStreamReturns struct {
+		result1 string
+		result2 error
+	}
+	uploadBlobVersionFromStreamReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	UploadFromStreamStub        func(string, io.Reader, int, time.Duration) error
 	uploadFromStreamMutex       sync.RWMutex
 	uploadFromStreamArgsForCall []struct {
@@ -118,15 +250,16 @@ func (fake *FakeAzureClient) CreateSnapshot(arg1 string) (time.Time, error) {
 	fake.createSnapshotArgsForCall = append(fake.createSnapshotArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.CreateSnapshotStub
+	fakeReturns := fake.createSnapshotReturns
 	fake.recordInvocation("CreateSnapshot", []interface{}{arg1})
 	fake.createSnapshotMutex.Unlock()
-	if fake.CreateSnapshotStub != nil {
-		return fake.CreateSnapshotStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.createSnapshotReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -185,15 +318,16 @@ func (fake *FakeAzureClient) DownloadBlobToFile(arg1 string, arg2 *os.File, arg3
 		arg4 int64
 		arg5 time.Duration
 	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.DownloadBlobToFileStub
+	fakeReturns := fake.downloadBlobToFileReturns
 	fake.recordInvocation("DownloadBlobToFile", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.downloadBlobToFileMutex.Unlock()
-	if fake.DownloadBlobToFileStub != nil {
-		return fake.DownloadBlobToFileStub(arg1, arg2, arg3, arg4, arg5)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.downloadBlobToFileReturns
 	return fakeReturns.result1
 }
 
@@ -239,6 +373,201 @@ func (fake *FakeAzureClient) DownloadBlobToFileReturnsOnCall(i int, result1 erro
 	}{result1}
 }
 
+func (fake *FakeAzureClient) DownloadBlobToFileIfUnchanged(arg1 string, arg2 *os.File, arg3 string, arg4 time.Time, arg5 int64, arg6 time.Duration) error {
+	fake.downloadBlobToFileIfUnchangedMutex.Lock()
+	ret, specificReturn := fake.downloadBlobToFileIfUnchangedReturnsOnCall[len(fake.downloadBlobToFileIfUnchangedArgsForCall)]
+	fake.downloadBlobToFileIfUnchangedArgsForCall = append(fake.downloadBlobToFileIfUnchangedArgsForCall, struct {
+		arg1 string
+		arg2 *os.File
+		arg3 string
+		arg4 time.Time
+		arg5 int64
+		arg6 time.Duration
+	}{arg1, arg2, arg3, arg4, arg5, arg6})
+	stub := fake.DownloadBlobToFileIfUnchangedStub
+	fakeReturns := fake.downloadBlobToFileIfUnchangedReturns
+	fake.recordInvocation("DownloadBlobToFileIfUnchanged", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6})
+	fake.downloadBlobToFileIfUnchangedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAzureClient) DownloadBlobToFileIfUnchangedCallCount() int {
+	fake.downloadBlobToFileIfUnchangedMutex.RLock()
+	defer fake.downloadBlobToFileIfUnchangedMutex.RUnlock()
+	return len(fake.downloadBlobToFileIfUnchangedArgsForCall)
+}
+
+func (fake *FakeAzureClient) DownloadBlobToFileIfUnchangedCalls(stub func(string, *os.File, string, time.Time, int64, time.Duration) error) {
+	fake.downloadBlobToFileIfUnchangedMutex.Lock()
+	defer fake.downloadBlobToFileIfUnchangedMutex.Unlock()
+	fake.DownloadBlobToFileIfUnchangedStub = stub
+}
+
+func (fake *FakeAzureClient) DownloadBlobToFileIfUnchangedArgsForCall(i int) (string, *os.File, string, time.Time, int64, time.Duration) {
+	fake.downloadBlobToFileIfUnchangedMutex.RLock()
+	defer fake.downloadBlobToFileIfUnchangedMutex.RUnlock()
+	argsForCall := fake.downloadBlobToFileIfUnchangedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
+}
+
+func (fake *FakeAzureClient) DownloadBlobToFileIfUnchangedReturns(result1 error) {
+	fake.downloadBlobToFileIfUnchangedMutex.Lock()
+	defer fake.downloadBlobToFileIfUnchangedMutex.Unlock()
+	fake.DownloadBlobToFileIfUnchangedStub = nil
+	fake.downloadBlobToFileIfUnchangedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureClient) DownloadBlobToFileIfUnchangedReturnsOnCall(i int, result1 error) {
+	fake.downloadBlobToFileIfUnchangedMutex.Lock()
+	defer fake.downloadBlobToFileIfUnchangedMutex.Unlock()
+	fake.DownloadBlobToFileIfUnchangedStub = nil
+	if fake.downloadBlobToFileIfUnchangedReturnsOnCall == nil {
+		fake.downloadBlobToFileIfUnchangedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.downloadBlobToFileIfUnchangedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureClient) DownloadBlobVersionToFile(arg1 string, arg2 string, arg3 *os.File, arg4 int64, arg5 time.Duration) error {
+	fake.downloadBlobVersionToFileMutex.Lock()
+	ret, specificReturn := fake.downloadBlobVersionToFileReturnsOnCall[len(fake.downloadBlobVersionToFileArgsForCall)]
+	fake.downloadBlobVersionToFileArgsForCall = append(fake.downloadBlobVersionToFileArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 *os.File
+		arg4 int64
+		arg5 time.Duration
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.DownloadBlobVersionToFileStub
+	fakeReturns := fake.downloadBlobVersionToFileReturns
+	fake.recordInvocation("DownloadBlobVersionToFile", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.downloadBlobVersionToFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeAzureClient) DownloadBlobVersionToFileCallCount() int {
+	fake.downloadBlobVersionToFileMutex.RLock()
+	defer fake.downloadBlobVersionToFileMutex.RUnlock()
+	return len(fake.downloadBlobVersionToFileArgsForCall)
+}
+
+func (fake *FakeAzureClient) DownloadBlobVersionToFileCalls(stub func(string, string, *os.File, int64, time.Duration) error) {
+	fake.downloadBlobVersionToFileMutex.Lock()
+	defer fake.downloadBlobVersionToFileMutex.Unlock()
+	fake.DownloadBlobVersionToFileStub = stub
+}
+
+func (fake *FakeAzureClient) DownloadBlobVersionToFileArgsForCall(i int) (string, string, *os.File, int64, time.Duration) {
+	fake.downloadBlobVersionToFileMutex.RLock()
+	defer fake.downloadBlobVersionToFileMutex.RUnlock()
+	argsForCall := fake.downloadBlobVersionToFileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeAzureClient) DownloadBlobVersionToFileReturns(result1 error) {
+	fake.downloadBlobVersionToFileMutex.Lock()
+	defer fake.downloadBlobVersionToFileMutex.Unlock()
+	fake.DownloadBlobVersionToFileStub = nil
+	fake.downloadBlobVersionToFileReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureClient) DownloadBlobVersionToFileReturnsOnCall(i int, result1 error) {
+	fake.downloadBlobVersionToFileMutex.Lock()
+	defer fake.downloadBlobVersionToFileMutex.Unlock()
+	fake.DownloadBlobVersionToFileStub = nil
+	if fake.downloadBlobVersionToFileReturnsOnCall == nil {
+		fake.downloadBlobVersionToFileReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.downloadBlobVersionToFileReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAzureClient) FindBlobsByTags(arg1 string) ([]azure.TaggedBlob, error) {
+	fake.findBlobsByTagsMutex.Lock()
+	ret, specificReturn := fake.findBlobsByTagsReturnsOnCall[len(fake.findBlobsByTagsArgsForCall)]
+	fake.findBlobsByTagsArgsForCall = append(fake.findBlobsByTagsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.FindBlobsByTagsStub
+	fakeReturns := fake.findBlobsByTagsReturns
+	fake.recordInvocation("FindBlobsByTags", []interface{}{arg1})
+	fake.findBlobsByTagsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureClient) FindBlobsByTagsCallCount() int {
+	fake.findBlobsByTagsMutex.RLock()
+	defer fake.findBlobsByTagsMutex.RUnlock()
+	return len(fake.findBlobsByTagsArgsForCall)
+}
+
+func (fake *FakeAzureClient) FindBlobsByTagsCalls(stub func(string) ([]azure.TaggedBlob, error)) {
+	fake.findBlobsByTagsMutex.Lock()
+	defer fake.findBlobsByTagsMutex.Unlock()
+	fake.FindBlobsByTagsStub = stub
+}
+
+func (fake *FakeAzureClient) FindBlobsByTagsArgsForCall(i int) string {
+	fake.findBlobsByTagsMutex.RLock()
+	defer fake.findBlobsByTagsMutex.RUnlock()
+	argsForCall := fake.findBlobsByTagsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureClient) FindBlobsByTagsReturns(result1 []azure.TaggedBlob, result2 error) {
+	fake.findBlobsByTagsMutex.Lock()
+	defer fake.findBlobsByTagsMutex.Unlock()
+	fake.FindBlobsByTagsStub = nil
+	fake.findBlobsByTagsReturns = struct {
+		result1 []azure.TaggedBlob
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureClient) FindBlobsByTagsReturnsOnCall(i int, result1 []azure.TaggedBlob, result2 error) {
+	fake.findBlobsByTagsMutex.Lock()
+	defer fake.findBlobsByTagsMutex.Unlock()
+	fake.FindBlobsByTagsStub = nil
+	if fake.findBlobsByTagsReturnsOnCall == nil {
+		fake.findBlobsByTagsReturnsOnCall = make(map[int]struct {
+			result1 []azure.TaggedBlob
+			result2 error
+		})
+	}
+	fake.findBlobsByTagsReturnsOnCall[i] = struct {
+		result1 []azure.TaggedBlob
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAzureClient) Get(arg1 string, arg2 time.Time) ([]byte, error) {
 	fake.getMutex.Lock()
 	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
@@ -246,15 +575,16 @@ func (fake *FakeAzureClient) Get(arg1 string, arg2 time.Time) ([]byte, error) {
 		arg1 string
 		arg2 time.Time
 	}{arg1, arg2})
+	stub := fake.GetStub
+	fakeReturns := fake.getReturns
 	fake.recordInvocation("Get", []interface{}{arg1, arg2})
 	fake.getMutex.Unlock()
-	if fake.GetStub != nil {
-		return fake.GetStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -310,15 +640,16 @@ func (fake *FakeAzureClient) GetBlobSizeInBytes(arg1 string, arg2 time.Time) (in
 		arg1 string
 		arg2 time.Time
 	}{arg1, arg2})
+	stub := fake.GetBlobSizeInBytesStub
+	fakeReturns := fake.getBlobSizeInBytesReturns
 	fake.recordInvocation("GetBlobSizeInBytes", []interface{}{arg1, arg2})
 	fake.getBlobSizeInBytesMutex.Unlock()
-	if fake.GetBlobSizeInBytesStub != nil {
-		return fake.GetBlobSizeInBytesStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getBlobSizeInBytesReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -367,21 +698,150 @@ func (fake *FakeAzureClient) GetBlobSizeInBytesReturnsOnCall(i int, result1 int6
 	}{result1, result2}
 }
 
+func (fake *FakeAzureClient) GetBlobState(arg1 string) (azure.BlobState, error) {
+	fake.getBlobStateMutex.Lock()
+	ret, specificReturn := fake.getBlobStateReturnsOnCall[len(fake.getBlobStateArgsForCall)]
+	fake.getBlobStateArgsForCall = append(fake.getBlobStateArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetBlobStateStub
+	fakeReturns := fake.getBlobStateReturns
+	fake.recordInvocation("GetBlobState", []interface{}{arg1})
+	fake.getBlobStateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureClient) GetBlobStateCallCount() int {
+	fake.getBlobStateMutex.RLock()
+	defer fake.getBlobStateMutex.RUnlock()
+	return len(fake.getBlobStateArgsForCall)
+}
+
+func (fake *FakeAzureClient) GetBlobStateCalls(stub func(string) (azure.BlobState, error)) {
+	fake.getBlobStateMutex.Lock()
+	defer fake.getBlobStateMutex.Unlock()
+	fake.GetBlobStateStub = stub
+}
+
+func (fake *FakeAzureClient) GetBlobStateArgsForCall(i int) string {
+	fake.getBlobStateMutex.RLock()
+	defer fake.getBlobStateMutex.RUnlock()
+	argsForCall := fake.getBlobStateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureClient) GetBlobStateReturns(result1 azure.BlobState, result2 error) {
+	fake.getBlobStateMutex.Lock()
+	defer fake.getBlobStateMutex.Unlock()
+	fake.GetBlobStateStub = nil
+	fake.getBlobStateReturns = struct {
+		result1 azure.BlobState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureClient) GetBlobStateReturnsOnCall(i int, result1 azure.BlobState, result2 error) {
+	fake.getBlobStateMutex.Lock()
+	defer fake.getBlobStateMutex.Unlock()
+	fake.GetBlobStateStub = nil
+	if fake.getBlobStateReturnsOnCall == nil {
+		fake.getBlobStateReturnsOnCall = make(map[int]struct {
+			result1 azure.BlobState
+			result2 error
+		})
+	}
+	fake.getBlobStateReturnsOnCall[i] = struct {
+		result1 azure.BlobState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureClient) GetBlobTags(arg1 string) (map[string]string, error) {
+	fake.getBlobTagsMutex.Lock()
+	ret, specificReturn := fake.getBlobTagsReturnsOnCall[len(fake.getBlobTagsArgsForCall)]
+	fake.getBlobTagsArgsForCall = append(fake.getBlobTagsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetBlobTagsStub
+	fakeReturns := fake.getBlobTagsReturns
+	fake.recordInvocation("GetBlobTags", []interface{}{arg1})
+	fake.getBlobTagsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureClient) GetBlobTagsCallCount() int {
+	fake.getBlobTagsMutex.RLock()
+	defer fake.getBlobTagsMutex.RUnlock()
+	return len(fake.getBlobTagsArgsForCall)
+}
+
+func (fake *FakeAzureClient) GetBlobTagsCalls(stub func(string) (map[string]string, error)) {
+	fake.getBlobTagsMutex.Lock()
+	defer fake.getBlobTagsMutex.Unlock()
+	fake.GetBlobTagsStub = stub
+}
+
+func (fake *FakeAzureClient) GetBlobTagsArgsForCall(i int) string {
+	fake.getBlobTagsMutex.RLock()
+	defer fake.getBlobTagsMutex.RUnlock()
+	argsForCall := fake.getBlobTagsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureClient) GetBlobTagsReturns(result1 map[string]string, result2 error) {
+	fake.getBlobTagsMutex.Lock()
+	defer fake.getBlobTagsMutex.Unlock()
+	fake.GetBlobTagsStub = nil
+	fake.getBlobTagsReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureClient) GetBlobTagsReturnsOnCall(i int, result1 map[string]string, result2 error) {
+	fake.getBlobTagsMutex.Lock()
+	defer fake.getBlobTagsMutex.Unlock()
+	fake.GetBlobTagsStub = nil
+	if fake.getBlobTagsReturnsOnCall == nil {
+		fake.getBlobTagsReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 error
+		})
+	}
+	fake.getBlobTagsReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAzureClient) GetBlobURL(arg1 string) (string, error) {
 	fake.getBlobURLMutex.Lock()
 	ret, specificReturn := fake.getBlobURLReturnsOnCall[len(fake.getBlobURLArgsForCall)]
 	fake.getBlobURLArgsForCall = append(fake.getBlobURLArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.GetBlobURLStub
+	fakeReturns := fake.getBlobURLReturns
 	fake.recordInvocation("GetBlobURL", []interface{}{arg1})
 	fake.getBlobURLMutex.Unlock()
-	if fake.GetBlobURLStub != nil {
-		return fake.GetBlobURLStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getBlobURLReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -430,21 +890,86 @@ func (fake *FakeAzureClient) GetBlobURLReturnsOnCall(i int, result1 string, resu
 	}{result1, result2}
 }
 
+func (fake *FakeAzureClient) ListBlobVersions(arg1 string) ([]azure.BlobVersion, error) {
+	fake.listBlobVersionsMutex.Lock()
+	ret, specificReturn := fake.listBlobVersionsReturnsOnCall[len(fake.listBlobVersionsArgsForCall)]
+	fake.listBlobVersionsArgsForCall = append(fake.listBlobVersionsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ListBlobVersionsStub
+	fakeReturns := fake.listBlobVersionsReturns
+	fake.recordInvocation("ListBlobVersions", []interface{}{arg1})
+	fake.listBlobVersionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureClient) ListBlobVersionsCallCount() int {
+	fake.listBlobVersionsMutex.RLock()
+	defer fake.listBlobVersionsMutex.RUnlock()
+	return len(fake.listBlobVersionsArgsForCall)
+}
+
+func (fake *FakeAzureClient) ListBlobVersionsCalls(stub func(string) ([]azure.BlobVersion, error)) {
+	fake.listBlobVersionsMutex.Lock()
+	defer fake.listBlobVersionsMutex.Unlock()
+	fake.ListBlobVersionsStub = stub
+}
+
+func (fake *FakeAzureClient) ListBlobVersionsArgsForCall(i int) string {
+	fake.listBlobVersionsMutex.RLock()
+	defer fake.listBlobVersionsMutex.RUnlock()
+	argsForCall := fake.listBlobVersionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureClient) ListBlobVersionsReturns(result1 []azure.BlobVersion, result2 error) {
+	fake.listBlobVersionsMutex.Lock()
+	defer fake.listBlobVersionsMutex.Unlock()
+	fake.ListBlobVersionsStub = nil
+	fake.listBlobVersionsReturns = struct {
+		result1 []azure.BlobVersion
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureClient) ListBlobVersionsReturnsOnCall(i int, result1 []azure.BlobVersion, result2 error) {
+	fake.listBlobVersionsMutex.Lock()
+	defer fake.listBlobVersionsMutex.Unlock()
+	fake.ListBlobVersionsStub = nil
+	if fake.listBlobVersionsReturnsOnCall == nil {
+		fake.listBlobVersionsReturnsOnCall = make(map[int]struct {
+			result1 []azure.BlobVersion
+			result2 error
+		})
+	}
+	fake.listBlobVersionsReturnsOnCall[i] = struct {
+		result1 []azure.BlobVersion
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAzureClient) ListBlobs(arg1 storage.ListBlobsParameters) (storage.BlobListResponse, error) {
 	fake.listBlobsMutex.Lock()
 	ret, specificReturn := fake.listBlobsReturnsOnCall[len(fake.listBlobsArgsForCall)]
 	fake.listBlobsArgsForCall = append(fake.listBlobsArgsForCall, struct {
 		arg1 storage.ListBlobsParameters
 	}{arg1})
+	stub := fake.ListBlobsStub
+	fakeReturns := fake.listBlobsReturns
 	fake.recordInvocation("ListBlobs", []interface{}{arg1})
 	fake.listBlobsMutex.Unlock()
-	if fake.ListBlobsStub != nil {
-		return fake.ListBlobsStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.listBlobsReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -493,6 +1018,210 @@ func (fake *FakeAzureClient) ListBlobsReturnsOnCall(i int, result1 storage.BlobL
 	}{result1, result2}
 }
 
+func (fake *FakeAzureClient) ListBlobsWithStates(arg1 storage.ListBlobsParameters) (storage.BlobListResponse, []azure.BlobState, error) {
+	fake.listBlobsWithStatesMutex.Lock()
+	ret, specificReturn := fake.listBlobsWithStatesReturnsOnCall[len(fake.listBlobsWithStatesArgsForCall)]
+	fake.listBlobsWithStatesArgsForCall = append(fake.listBlobsWithStatesArgsForCall, struct {
+		arg1 storage.ListBlobsParameters
+	}{arg1})
+	stub := fake.ListBlobsWithStatesStub
+	fakeReturns := fake.listBlobsWithStatesReturns
+	fake.recordInvocation("ListBlobsWithStates", []interface{}{arg1})
+	fake.listBlobsWithStatesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeAzureClient) ListBlobsWithStatesCallCount() int {
+	fake.listBlobsWithStatesMutex.RLock()
+	defer fake.listBlobsWithStatesMutex.RUnlock()
+	return len(fake.listBlobsWithStatesArgsForCall)
+}
+
+func (fake *FakeAzureClient) ListBlobsWithStatesCalls(stub func(storage.ListBlobsParameters) (storage.BlobListResponse, []azure.BlobState, error)) {
+	fake.listBlobsWithStatesMutex.Lock()
+	defer fake.listBlobsWithStatesMutex.Unlock()
+	fake.ListBlobsWithStatesStub = stub
+}
+
+func (fake *FakeAzureClient) ListBlobsWithStatesArgsForCall(i int) storage.ListBlobsParameters {
+	fake.listBlobsWithStatesMutex.RLock()
+	defer fake.listBlobsWithStatesMutex.RUnlock()
+	argsForCall := fake.listBlobsWithStatesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAzureClient) ListBlobsWithStatesReturns(result1 storage.BlobListResponse, result2 []azure.BlobState, result3 error) {
+	fake.listBlobsWithStatesMutex.Lock()
+	defer fake.listBlobsWithStatesMutex.Unlock()
+	fake.ListBlobsWithStatesStub = nil
+	fake.listBlobsWithStatesReturns = struct {
+		result1 storage.BlobListResponse
+		result2 []azure.BlobState
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeAzureClient) ListBlobsWithStatesReturnsOnCall(i int, result1 storage.BlobListResponse, result2 []azure.BlobState, result3 error) {
+	fake.listBlobsWithStatesMutex.Lock()
+	defer fake.listBlobsWithStatesMutex.Unlock()
+	fake.ListBlobsWithStatesStub = nil
+	if fake.listBlobsWithStatesReturnsOnCall == nil {
+		fake.listBlobsWithStatesReturnsOnCall = make(map[int]struct {
+			result1 storage.BlobListResponse
+			result2 []azure.BlobState
+			result3 error
+		})
+	}
+	fake.listBlobsWithStatesReturnsOnCall[i] = struct {
+		result1 storage.BlobListResponse
+		result2 []azure.BlobState
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeAzureClient) UploadBlobETagFromStream(arg1 string, arg2 io.Reader, arg3 int, arg4 time.Duration) (string, time.Time, error) {
+	fake.uploadBlobETagFromStreamMutex.Lock()
+	ret, specificReturn := fake.uploadBlobETagFromStreamReturnsOnCall[len(fake.uploadBlobETagFromStreamArgsForCall)]
+	fake.uploadBlobETagFromStreamArgsForCall = append(fake.uploadBlobETagFromStreamArgsForCall, struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 int
+		arg4 time.Duration
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.UploadBlobETagFromStreamStub
+	fakeReturns := fake.uploadBlobETagFromStreamReturns
+	fake.recordInvocation("UploadBlobETagFromStream", []interface{}{arg1, arg2, arg3, arg4})
+	fake.uploadBlobETagFromStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeAzureClient) UploadBlobETagFromStreamCallCount() int {
+	fake.uploadBlobETagFromStreamMutex.RLock()
+	defer fake.uploadBlobETagFromStreamMutex.RUnlock()
+	return len(fake.uploadBlobETagFromStreamArgsForCall)
+}
+
+func (fake *FakeAzureClient) UploadBlobETagFromStreamCalls(stub func(string, io.Reader, int, time.Duration) (string, time.Time, error)) {
+	fake.uploadBlobETagFromStreamMutex.Lock()
+	defer fake.uploadBlobETagFromStreamMutex.Unlock()
+	fake.UploadBlobETagFromStreamStub = stub
+}
+
+func (fake *FakeAzureClient) UploadBlobETagFromStreamArgsForCall(i int) (string, io.Reader, int, time.Duration) {
+	fake.uploadBlobETagFromStreamMutex.RLock()
+	defer fake.uploadBlobETagFromStreamMutex.RUnlock()
+	argsForCall := fake.uploadBlobETagFromStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeAzureClient) UploadBlobETagFromStreamReturns(result1 string, result2 time.Time, result3 error) {
+	fake.uploadBlobETagFromStreamMutex.Lock()
+	defer fake.uploadBlobETagFromStreamMutex.Unlock()
+	fake.UploadBlobETagFromStreamStub = nil
+	fake.uploadBlobETagFromStreamReturns = struct {
+		result1 string
+		result2 time.Time
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeAzureClient) UploadBlobETagFromStreamReturnsOnCall(i int, result1 string, result2 time.Time, result3 error) {
+	fake.uploadBlobETagFromStreamMutex.Lock()
+	defer fake.uploadBlobETagFromStreamMutex.Unlock()
+	fake.UploadBlobETagFromStreamStub = nil
+	if fake.uploadBlobETagFromStreamReturnsOnCall == nil {
+		fake.uploadBlobETagFromStreamReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 time.Time
+			result3 error
+		})
+	}
+	fake.uploadBlobETagFromStreamReturnsOnCall[i] = struct {
+		result1 string
+		result2 time.Time
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeAzureClient) UploadBlobVersionFromStream(arg1 string, arg2 io.Reader, arg3 int, arg4 time.Duration) (string, error) {
+	fake.uploadBlobVersionFromStreamMutex.Lock()
+	ret, specificReturn := fake.uploadBlobVersionFromStreamReturnsOnCall[len(fake.uploadBlobVersionFromStreamArgsForCall)]
+	fake.uploadBlobVersionFromStreamArgsForCall = append(fake.uploadBlobVersionFromStreamArgsForCall, struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 int
+		arg4 time.Duration
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.UploadBlobVersionFromStreamStub
+	fakeReturns := fake.uploadBlobVersionFromStreamReturns
+	fake.recordInvocation("UploadBlobVersionFromStream", []interface{}{arg1, arg2, arg3, arg4})
+	fake.uploadBlobVersionFromStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAzureClient) UploadBlobVersionFromStreamCallCount() int {
+	fake.uploadBlobVersionFromStreamMutex.RLock()
+	defer fake.uploadBlobVersionFromStreamMutex.RUnlock()
+	return len(fake.uploadBlobVersionFromStreamArgsForCall)
+}
+
+func (fake *FakeAzureClient) UploadBlobVersionFromStreamCalls(stub func(string, io.Reader, int, time.Duration) (string, error)) {
+	fake.uploadBlobVersionFromStreamMutex.Lock()
+	defer fake.uploadBlobVersionFromStreamMutex.Unlock()
+	fake.UploadBlobVersionFromStreamStub = stub
+}
+
+func (fake *FakeAzureClient) UploadBlobVersionFromStreamArgsForCall(i int) (string, io.Reader, int, time.Duration) {
+	fake.uploadBlobVersionFromStreamMutex.RLock()
+	defer fake.uploadBlobVersionFromStreamMutex.RUnlock()
+	argsForCall := fake.uploadBlobVersionFromStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeAzureClient) UploadBlobVersionFromStreamReturns(result1 string, result2 error) {
+	fake.uploadBlobVersionFromStreamMutex.Lock()
+	defer fake.uploadBlobVersionFromStreamMutex.Unlock()
+	fake.UploadBlobVersionFromStreamStub = nil
+	fake.uploadBlobVersionFromStreamReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAzureClient) UploadBlobVersionFromStreamReturnsOnCall(i int, result1 string, result2 error) {
+	fake.uploadBlobVersionFromStreamMutex.Lock()
+	defer fake.uploadBlobVersionFromStreamMutex.Unlock()
+	fake.UploadBlobVersionFromStreamStub = nil
+	if fake.uploadBlobVersionFromStreamReturnsOnCall == nil {
+		fake.uploadBlobVersionFromStreamReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.uploadBlobVersionFromStreamReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAzureClient) UploadFromStream(arg1 string, arg2 io.Reader, arg3 int, arg4 time.Duration) error {
 	fake.uploadFromStreamMutex.Lock()
 	ret, specificReturn := fake.uploadFromStreamReturnsOnCall[len(fake.uploadFromStreamArgsForCall)]
@@ -502,15 +1231,16 @@ func (fake *FakeAzureClient) UploadFromStream(arg1 string, arg2 io.Reader, arg3
 		arg3 int
 		arg4 time.Duration
 	}{arg1, arg2, arg3, arg4})
+	stub := fake.UploadFromStreamStub
+	fakeReturns := fake.uploadFromStreamReturns
 	fake.recordInvocation("UploadFromStream", []interface{}{arg1, arg2, arg3, arg4})
 	fake.uploadFromStreamMutex.Unlock()
-	if fake.UploadFromStreamStub != nil {
-		return fake.UploadFromStreamStub(arg1, arg2, arg3, arg4)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.uploadFromStreamReturns
 	return fakeReturns.result1
 }
 
@@ -563,14 +1293,32 @@ func (fake *FakeAzureClient) Invocations() map[string][][]interface{} {
 	defer fake.createSnapshotMutex.RUnlock()
 	fake.downloadBlobToFileMutex.RLock()
 	defer fake.downloadBlobToFileMutex.RUnlock()
+	fake.downloadBlobToFileIfUnchangedMutex.RLock()
+	defer fake.downloadBlobToFileIfUnchangedMutex.RUnlock()
+	fake.downloadBlobVersionToFileMutex.RLock()
+	defer fake.downloadBlobVersionToFileMutex.RUnlock()
+	fake.findBlobsByTagsMutex.RLock()
+	defer fake.findBlobsByTagsMutex.RUnlock()
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
 	fake.getBlobSizeInBytesMutex.RLock()
 	defer fake.getBlobSizeInBytesMutex.RUnlock()
+	fake.getBlobStateMutex.RLock()
+	defer fake.getBlobStateMutex.RUnlock()
+	fake.getBlobTagsMutex.RLock()
+	defer fake.getBlobTagsMutex.RUnlock()
 	fake.getBlobURLMutex.RLock()
 	defer fake.getBlobURLMutex.RUnlock()
+	fake.listBlobVersionsMutex.RLock()
+	defer fake.listBlobVersionsMutex.RUnlock()
 	fake.listBlobsMutex.RLock()
 	defer fake.listBlobsMutex.RUnlock()
+	fake.listBlobsWithStatesMutex.RLock()
+	defer fake.listBlobsWithStatesMutex.RUnlock()
+	fake.uploadBlobETagFromStreamMutex.RLock()
+	defer fake.uploadBlobETagFromStreamMutex.RUnlock()
+	fake.uploadBlobVersionFromStreamMutex.RLock()
+	defer fake.uploadBlobVersionFromStreamMutex.RUnlock()
 	fake.uploadFromStreamMutex.RLock()
 	defer fake.uploadFromStreamMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
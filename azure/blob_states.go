@@ -0,0 +1,65 @@
+package azure
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// BlobState is the access tier, rehydration and lease state of a blob, which
+// decide whether it can be read.
+type BlobState struct {
+	AccessTier    string
+	ArchiveStatus string
+	LeaseState    string
+}
+
+// Archived reports whether the blob is in the archive tier and cannot be
+// read, including while it is being rehydrated.
+func (s BlobState) Archived() bool {
+	return s.AccessTier == string(azblob.AccessTierArchive)
+}
+
+// Rehydrating reports whether the blob is being rehydrated from the archive
+// tier. Rehydrating blobs are also archived.
+func (s BlobState) Rehydrating() bool {
+	return strings.HasPrefix(s.ArchiveStatus, "rehydrate-pending")
+}
+
+// Leased reports whether a writer holds a lease on the blob.
+func (s BlobState) Leased() bool {
+	return s.LeaseState == string(azblob.LeaseStateLeased)
+}
+
+// GetBlobState returns the state of the current version of blobName.
+func (c Client) GetBlobState(blobName string) (BlobState, error) {
+	err := c.requireSASPermission(sasPermissionRead)
+	if err != nil {
+		return BlobState{}, err
+	}
+
+	blobURL, err := c.blobURL(blobName, nil, 0)
+	if err != nil {
+		return BlobState{}, err
+	}
+
+	properties, err := blobURL.GetProperties(context.Background(), azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+	if err != nil {
+		return BlobState{}, err
+	}
+
+	return BlobState{
+		AccessTier:    properties.AccessTier(),
+		ArchiveStatus: properties.ArchiveStatus(),
+		LeaseState:    string(properties.LeaseState()),
+	}, nil
+}
+
+func blobState(properties azblob.BlobProperties) BlobState {
+	return BlobState{
+		AccessTier:    string(properties.AccessTier),
+		ArchiveStatus: string(properties.ArchiveStatus),
+		LeaseState:    string(properties.LeaseState),
+	}
+}
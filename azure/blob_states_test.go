@@ -0,0 +1,129 @@
+package azure_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+)
+
+var _ = Describe("Blob states", func() {
+	var (
+		server   *httptest.Server
+		requests []*http.Request
+		client   azure.Client
+	)
+
+	BeforeEach(func() {
+		requests = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+
+			if r.Method == http.MethodHead {
+				w.Header().Set("x-ms-access-tier", "Archive")
+				w.Header().Set("x-ms-archive-status", "rehydrate-pending-to-cool")
+				w.Header().Set("x-ms-lease-state", "available")
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults ContainerName="some-container">
+  <Blobs>
+    <Blob>
+      <Name>example.json</Name>
+      <Snapshot>2017-01-01T01:01:01.0000000Z</Snapshot>
+      <Properties>
+        <AccessTier>Archive</AccessTier>
+        <ArchiveStatus>rehydrate-pending-to-hot</ArchiveStatus>
+      </Properties>
+    </Blob>
+    <Blob>
+      <Name>example.json</Name>
+      <Properties>
+        <AccessTier>Hot</AccessTier>
+        <LeaseState>leased</LeaseState>
+      </Properties>
+    </Blob>
+  </Blobs>
+  <NextMarker />
+</EnumerationResults>`)
+		}))
+
+		var err error
+		client, err = azure.NewClient(azure.Config{
+			BlobEndpoint:      server.URL + "/devstoreaccount1",
+			StorageAccountKey: "c29tZS1rZXk=",
+			Container:         "some-container",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("ListBlobsWithStates", func() {
+		It("returns the state of every listed blob from the same listing", func() {
+			response, states, err := client.ListBlobsWithStates(storage.ListBlobsParameters{
+				Prefix:  "example.json",
+				Include: &storage.IncludeBlobDataset{Snapshots: true},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL.Query().Get("prefix")).To(Equal("example.json"))
+			Expect(requests[0].URL.Query().Get("include")).To(Equal("snapshots"))
+
+			Expect(response.Blobs).To(HaveLen(2))
+			Expect(response.Blobs[0].Snapshot).To(Equal(time.Date(2017, time.January, 1, 1, 1, 1, 0, time.UTC)))
+			Expect(response.Blobs[1].Properties.LeaseState).To(Equal("leased"))
+
+			Expect(states).To(Equal([]azure.BlobState{
+				{AccessTier: "Archive", ArchiveStatus: "rehydrate-pending-to-hot"},
+				{AccessTier: "Hot", LeaseState: "leased"},
+			}))
+		})
+	})
+
+	Describe("GetBlobState", func() {
+		It("returns the state from the properties of the blob", func() {
+			state, err := client.GetBlobState("releases/example.json")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal(http.MethodHead))
+			Expect(requests[0].URL.Path).To(Equal("/devstoreaccount1/some-container/releases/example.json"))
+
+			Expect(state).To(Equal(azure.BlobState{
+				AccessTier:    "Archive",
+				ArchiveStatus: "rehydrate-pending-to-cool",
+				LeaseState:    "available",
+			}))
+		})
+	})
+
+	Describe("BlobState", func() {
+		It("is archived in the archive tier, even while rehydrating", func() {
+			Expect(azure.BlobState{AccessTier: "Archive"}.Archived()).To(BeTrue())
+			Expect(azure.BlobState{AccessTier: "Archive", ArchiveStatus: "rehydrate-pending-to-cool"}.Archived()).To(BeTrue())
+			Expect(azure.BlobState{AccessTier: "Cool"}.Archived()).To(BeFalse())
+		})
+
+		It("is rehydrating while a rehydration is pending", func() {
+			Expect(azure.BlobState{AccessTier: "Archive", ArchiveStatus: "rehydrate-pending-to-hot"}.Rehydrating()).To(BeTrue())
+			Expect(azure.BlobState{AccessTier: "Hot"}.Rehydrating()).To(BeFalse())
+		})
+
+		It("is leased while a lease is held", func() {
+			Expect(azure.BlobState{LeaseState: "leased"}.Leased()).To(BeTrue())
+			Expect(azure.BlobState{LeaseState: "breaking"}.Leased()).To(BeFalse())
+		})
+	})
+})
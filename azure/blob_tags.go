@@ -0,0 +1,92 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// TaggedBlob is a blob found by its index tags. Tags holds the tags that
+// were referenced by the filter expression.
+type TaggedBlob struct {
+	Name string
+	Tags map[string]string
+}
+
+// FindBlobsByTags returns the blobs of the container whose index tags match
+// the expression, e.g. "env = 'prod' AND stage = 'green'". Blobs are found
+// across the storage account, so a SAS token must be an account SAS.
+func (c Client) FindBlobsByTags(expression string) ([]TaggedBlob, error) {
+	err := c.requireAccountSAS()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.requireSASPermission(sasPermissionFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceURL, err := c.serviceURL(0)
+	if err != nil {
+		return nil, err
+	}
+
+	where := fmt.Sprintf("@container = '%s' AND %s", strings.ReplaceAll(c.container, "'", "''"), expression)
+
+	var blobs []TaggedBlob
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		response, err := serviceURL.FindBlobsByTags(context.Background(), nil, nil, &where, marker, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Blobs {
+			blobs = append(blobs, TaggedBlob{
+				Name: item.Name,
+				Tags: blobTags(item.Tags),
+			})
+		}
+
+		if response.NextMarker == nil {
+			break
+		}
+		marker = azblob.Marker{Val: response.NextMarker}
+	}
+
+	return blobs, nil
+}
+
+// GetBlobTags returns all index tags of the blob.
+func (c Client) GetBlobTags(blobName string) (map[string]string, error) {
+	err := c.requireSASPermission(sasPermissionTags)
+	if err != nil {
+		return nil, err
+	}
+
+	blobURL, err := c.blobURL(blobName, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := blobURL.GetTags(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return blobTags(tags), nil
+}
+
+func blobTags(tags *azblob.BlobTags) map[string]string {
+	result := map[string]string{}
+	if tags == nil {
+		return result
+	}
+
+	for _, tag := range tags.BlobTagSet {
+		result[tag.Key] = tag.Value
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package azure_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+)
+
+var _ = Describe("Blob tags", func() {
+	var (
+		server   *httptest.Server
+		requests []*http.Request
+		client   azure.Client
+	)
+
+	BeforeEach(func() {
+		requests = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+
+			w.Header().Set("Content-Type", "application/xml")
+			if r.URL.Query().Get("comp") == "tags" {
+				fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>
+<Tags>
+  <TagSet>
+    <Tag><Key>env</Key><Value>prod</Value></Tag>
+    <Tag><Key>release</Key><Value>1.2.0</Value></Tag>
+  </TagSet>
+</Tags>`)
+				return
+			}
+
+			if r.URL.Query().Get("marker") == "" {
+				fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults>
+  <Blobs>
+    <Blob>
+      <Name>product-1.2.0.tgz</Name>
+      <ContainerName>some-container</ContainerName>
+      <Tags><TagSet><Tag><Key>env</Key><Value>prod</Value></Tag></TagSet></Tags>
+    </Blob>
+  </Blobs>
+  <NextMarker>page-2</NextMarker>
+</EnumerationResults>`)
+				return
+			}
+
+			fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults>
+  <Blobs>
+    <Blob>
+      <Name>product-1.3.0.tgz</Name>
+      <ContainerName>some-container</ContainerName>
+    </Blob>
+  </Blobs>
+  <NextMarker />
+</EnumerationResults>`)
+		}))
+
+		var err error
+		client, err = azure.NewClient(azure.Config{
+			BlobEndpoint:      server.URL + "/devstoreaccount1",
+			StorageAccountKey: "c29tZS1rZXk=",
+			Container:         "some-container",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("FindBlobsByTags", func() {
+		It("finds the blobs of the container matching the expression", func() {
+			blobs, err := client.FindBlobsByTags("env = 'prod'")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(blobs).To(Equal([]azure.TaggedBlob{
+				{Name: "product-1.2.0.tgz", Tags: map[string]string{"env": "prod"}},
+				{Name: "product-1.3.0.tgz", Tags: map[string]string{}},
+			}))
+
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[0].URL.Path).To(Equal("/devstoreaccount1"))
+			Expect(requests[0].URL.Query().Get("comp")).To(Equal("blobs"))
+			Expect(requests[0].URL.Query().Get("where")).To(Equal("@container = 'some-container' AND env = 'prod'"))
+			Expect(requests[1].URL.Query().Get("marker")).To(Equal("page-2"))
+		})
+	})
+
+	Describe("GetBlobTags", func() {
+		It("returns the tags of the blob", func() {
+			tags, err := client.GetBlobTags("product-1.2.0.tgz")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tags).To(Equal(map[string]string{"env": "prod", "release": "1.2.0"}))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL.Path).To(Equal("/devstoreaccount1/some-container/product-1.2.0.tgz"))
+			Expect(requests[0].URL.Query().Get("comp")).To(Equal("tags"))
+		})
+	})
+})
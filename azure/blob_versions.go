@@ -0,0 +1,104 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+var errVersioningDisabled = errors.New("the upload did not create a blob version, blob versioning must be enabled on the storage account")
+
+// BlobVersion is a version of a blob in a storage account with blob
+// versioning enabled.
+type BlobVersion struct {
+	Name             string
+	VersionID        string
+	IsCurrentVersion bool
+	LastModified     time.Time
+	CopyStatus       string
+	State            BlobState
+}
+
+// ListBlobVersions returns every version of the blobs whose names start with
+// prefix.
+func (c Client) ListBlobVersions(prefix string) ([]BlobVersion, error) {
+	err := c.requireSASPermission(sasPermissionList)
+	if err != nil {
+		return nil, err
+	}
+
+	containerURL, err := c.containerURL(0)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []BlobVersion
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		response, err := containerURL.ListBlobsFlatSegment(context.Background(), marker, azblob.ListBlobsSegmentOptions{
+			Prefix: prefix,
+			Details: azblob.BlobListingDetails{
+				Copy:     true,
+				Versions: true,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Segment.BlobItems {
+			if item.VersionID == nil {
+				continue
+			}
+
+			versions = append(versions, BlobVersion{
+				Name:             item.Name,
+				VersionID:        *item.VersionID,
+				IsCurrentVersion: item.IsCurrentVersion != nil && *item.IsCurrentVersion,
+				LastModified:     item.Properties.LastModified.UTC(),
+				CopyStatus:       string(item.Properties.CopyStatus),
+				State:            blobState(item.Properties),
+			})
+		}
+
+		marker = response.NextMarker
+	}
+
+	return versions, nil
+}
+
+// DownloadBlobVersionToFile downloads the given version of blobName to file.
+func (c Client) DownloadBlobVersionToFile(blobName, versionID string, file *os.File, blockSize int64, retryTryTimeout time.Duration) error {
+	err := c.requireSASPermission(sasPermissionRead)
+	if err != nil {
+		return err
+	}
+
+	blobURL, err := c.blobURL(blobName, nil, retryTryTimeout)
+	if err != nil {
+		return err
+	}
+
+	return azblob.DownloadBlobToFile(context.Background(), blobURL.WithVersionID(versionID), 0, 0, file, azblob.DownloadFromBlobOptions{
+		BlockSize: blockSize,
+	})
+}
+
+// UploadBlobVersionFromStream uploads stream to blobName and returns the id
+// of the blob version the upload created.
+func (c Client) UploadBlobVersionFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) (string, error) {
+	response, err := c.uploadFromStream(blobName, stream, blockSize, retryTryTimeout)
+	if err != nil {
+		return "", err
+	}
+
+	versioned, ok := response.(interface{ VersionID() string })
+	if !ok || versioned.VersionID() == "" {
+		return "", errVersioningDisabled
+	}
+
+	return versioned.VersionID(), nil
+}
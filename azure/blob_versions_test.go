@@ -0,0 +1,127 @@
+package azure_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+)
+
+var _ = Describe("Blob versions", func() {
+	var (
+		server    *httptest.Server
+		requests  []*http.Request
+		versionID string
+		client    azure.Client
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		versionID = "2017-01-03T01:01:01.0000000Z"
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+
+			if r.Method == http.MethodPut {
+				if versionID != "" {
+					w.Header().Set("x-ms-version-id", versionID)
+				}
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/xml")
+			if r.URL.Query().Get("marker") == "" {
+				fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults ContainerName="some-container">
+  <Blobs>
+    <Blob>
+      <Name>example.json</Name>
+      <VersionId>2017-01-01T01:01:01.0000000Z</VersionId>
+      <Properties>
+        <Last-Modified>Sun, 01 Jan 2017 01:01:01 GMT</Last-Modified>
+      </Properties>
+    </Blob>
+  </Blobs>
+  <NextMarker>page-2</NextMarker>
+</EnumerationResults>`)
+				return
+			}
+
+			fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults ContainerName="some-container">
+  <Blobs>
+    <Blob>
+      <Name>example.json</Name>
+      <VersionId>2017-01-02T01:01:01.0000000Z</VersionId>
+      <IsCurrentVersion>true</IsCurrentVersion>
+      <Properties>
+        <Last-Modified>Mon, 02 Jan 2017 01:01:01 GMT</Last-Modified>
+        <CopyStatus>success</CopyStatus>
+      </Properties>
+    </Blob>
+  </Blobs>
+  <NextMarker />
+</EnumerationResults>`)
+		}))
+
+		var err error
+		client, err = azure.NewClient(azure.Config{
+			BlobEndpoint:      server.URL + "/devstoreaccount1",
+			StorageAccountKey: "c29tZS1rZXk=",
+			Container:         "some-container",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("ListBlobVersions", func() {
+		It("lists the versions on every page", func() {
+			versions, err := client.ListBlobVersions("example.json")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[0].URL.Query().Get("prefix")).To(Equal("example.json"))
+			Expect(requests[0].URL.Query().Get("include")).To(Equal("copy,versions"))
+			Expect(requests[1].URL.Query().Get("marker")).To(Equal("page-2"))
+
+			Expect(versions).To(Equal([]azure.BlobVersion{
+				{
+					Name:         "example.json",
+					VersionID:    "2017-01-01T01:01:01.0000000Z",
+					LastModified: time.Date(2017, time.January, 1, 1, 1, 1, 0, time.UTC),
+				},
+				{
+					Name:             "example.json",
+					VersionID:        "2017-01-02T01:01:01.0000000Z",
+					IsCurrentVersion: true,
+					LastModified:     time.Date(2017, time.January, 2, 1, 1, 1, 0, time.UTC),
+					CopyStatus:       "success",
+				},
+			}))
+		})
+	})
+
+	Describe("UploadBlobVersionFromStream", func() {
+		It("returns the id of the version created by the upload", func() {
+			id, err := client.UploadBlobVersionFromStream("example.json", bytes.NewBufferString("some-content"), 1024, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(id).To(Equal("2017-01-03T01:01:01.0000000Z"))
+		})
+
+		It("returns an error when blob versioning is disabled", func() {
+			versionID = ""
+
+			_, err := client.UploadBlobVersionFromStream("example.json", bytes.NewBufferString("some-content"), 1024, 0)
+			Expect(err).To(MatchError(ContainSubstring("blob versioning must be enabled")))
+		})
+	})
+})
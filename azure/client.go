@@ -2,11 +2,15 @@ package azure
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
@@ -18,101 +22,196 @@ const (
 	SnapshotTimeFormat = "2006-01-02T15:04:05.0000000Z"
 )
 
+var errAnonymousWrite = errors.New("credentials are required to write blobs")
+
+// ErrVersionNotAvailable is returned by DownloadBlobToFileIfUnchanged when the
+// blob has been overwritten since its ETag or last modified time was read.
+var ErrVersionNotAvailable = errors.New("version no longer available, the blob has been overwritten")
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . AzureClient
 type AzureClient interface {
 	ListBlobs(params storage.ListBlobsParameters) (storage.BlobListResponse, error)
 	GetBlobSizeInBytes(blobName string, snapshot time.Time) (int64, error)
 	Get(blobName string, snapshot time.Time) ([]byte, error)
 	DownloadBlobToFile(blobName string, file *os.File, snapshop *time.Time, blockSize int64, retryTryTimeout time.Duration) error
+	DownloadBlobToFileIfUnchanged(blobName string, file *os.File, etag string, lastModified time.Time, blockSize int64, retryTryTimeout time.Duration) error
 	UploadFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) error
 	CreateSnapshot(blobName string) (time.Time, error)
 	GetBlobURL(blobName string) (string, error)
+	ListBlobVersions(prefix string) ([]BlobVersion, error)
+	DownloadBlobVersionToFile(blobName, versionID string, file *os.File, blockSize int64, retryTryTimeout time.Duration) error
+	UploadBlobVersionFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) (string, error)
+	UploadBlobETagFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) (string, time.Time, error)
+	FindBlobsByTags(expression string) ([]TaggedBlob, error)
+	GetBlobTags(blobName string) (map[string]string, error)
+	ListBlobsWithStates(params storage.ListBlobsParameters) (storage.BlobListResponse, []BlobState, error)
+	GetBlobState(blobName string) (BlobState, error)
+}
+
+// Config describes the storage account and container a Client talks to and
+// how it authenticates. BlobEndpoint, when set, replaces the endpoint derived
+// from StorageAccountName and BaseURL. StorageAccountKey takes precedence
+// over SASToken, which takes precedence over TokenSource. Without any of
+// them requests are made anonymously. SecondaryStorageAccountKey is used
+// when the service rejects StorageAccountKey, e.g. while keys are rotated.
+// HTTPClient, when set, sends all requests; see NewHTTPClient.
+// ReadFromSecondary retries failed reads against the read-access
+// geo-redundant secondary endpoint of the account.
+type Config struct {
+	BaseURL                    string
+	BlobEndpoint               string
+	StorageAccountName         string
+	StorageAccountKey          string
+	SecondaryStorageAccountKey string
+	SASToken                   string
+	TokenSource                TokenSource
+	Container                  string
+	HTTPClient                 *http.Client
+	ReadFromSecondary          bool
 }
 
 type Client struct {
-	baseURL            string
-	storageAccountName string
-	storageAccountKey  string
-	container          string
+	baseURL                    string
+	blobEndpoint               string
+	storageAccountName         string
+	storageAccountKey          string
+	secondaryStorageAccountKey string
+	sasToken                   url.Values
+	tokenSource                TokenSource
+	container                  string
+	httpClient                 *http.Client
+	readFromSecondary          bool
 }
 
-func NewClient(baseURL, storageAccountName, storageAccountKey, container string) Client {
-	return Client{
-		baseURL:            baseURL,
-		storageAccountName: storageAccountName,
-		storageAccountKey:  storageAccountKey,
-		container:          container,
+func NewClient(config Config) (Client, error) {
+	sasToken, err := url.ParseQuery(strings.TrimPrefix(config.SASToken, "?"))
+	if err != nil {
+		return Client{}, fmt.Errorf("failed to parse sas token: %s", err)
+	}
+
+	blobEndpoint := strings.TrimSuffix(config.BlobEndpoint, "/")
+	storageAccountName := config.StorageAccountName
+	if blobEndpoint != "" {
+		u, err := url.Parse(blobEndpoint)
+		if err != nil {
+			return Client{}, fmt.Errorf("failed to parse blob endpoint: %s", err)
+		}
+
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return Client{}, fmt.Errorf("blob endpoint must be an http or https url: %q", config.BlobEndpoint)
+		}
+
+		if storageAccountName == "" {
+			storageAccountName = accountNameFromEndpoint(u)
+		}
+
+		if config.ReadFromSecondary && isPathStyle(u) {
+			return Client{}, fmt.Errorf("reading from the secondary endpoint requires a blob endpoint with the account in the host name: %q", config.BlobEndpoint)
+		}
 	}
+
+	return Client{
+		baseURL:                    config.BaseURL,
+		blobEndpoint:               blobEndpoint,
+		storageAccountName:         storageAccountName,
+		storageAccountKey:          config.StorageAccountKey,
+		secondaryStorageAccountKey: config.SecondaryStorageAccountKey,
+		sasToken:                   sasToken,
+		tokenSource:                config.TokenSource,
+		container:                  config.Container,
+		httpClient:                 config.HTTPClient,
+		readFromSecondary:          config.ReadFromSecondary,
+	}, nil
 }
 
 func (c Client) ListBlobs(params storage.ListBlobsParameters) (storage.BlobListResponse, error) {
-	client, err := storage.NewClient(c.storageAccountName, c.storageAccountKey, c.baseURL, storage.DefaultAPIVersion, true)
+	response, _, err := c.ListBlobsWithStates(params)
+	return response, err
+}
+
+// ListBlobsWithStates lists blobs like ListBlobs and also returns the state
+// of each listed blob, in the order of the blobs of the response, as
+// storage.Blob has no access tier or archive status.
+func (c Client) ListBlobsWithStates(params storage.ListBlobsParameters) (storage.BlobListResponse, []BlobState, error) {
+	err := c.requireSASPermission(sasPermissionList)
 	if err != nil {
-		return storage.BlobListResponse{}, err
+		return storage.BlobListResponse{}, nil, err
 	}
 
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(c.container)
+	containerURL, err := c.containerURL(0)
+	if err != nil {
+		return storage.BlobListResponse{}, nil, err
+	}
 
-	return cnt.ListBlobs(params)
-}
+	var marker azblob.Marker
+	if params.Marker != "" {
+		marker.Val = &params.Marker
+	}
 
-func (c Client) GetBlobSizeInBytes(blobName string, snapshot time.Time) (int64, error) {
-	client, err := storage.NewClient(c.storageAccountName, c.storageAccountKey, c.baseURL, storage.DefaultAPIVersion, true)
+	options := azblob.ListBlobsSegmentOptions{
+		Prefix:     params.Prefix,
+		MaxResults: int32(params.MaxResults),
+	}
+	if params.Include != nil {
+		options.Details = azblob.BlobListingDetails{
+			Snapshots:        params.Include.Snapshots,
+			Metadata:         params.Include.Metadata,
+			UncommittedBlobs: params.Include.UncommittedBlobs,
+			Copy:             params.Include.Copy,
+		}
+	}
+
+	response, err := containerURL.ListBlobsFlatSegment(context.Background(), marker, options)
 	if err != nil {
-		return 0, err
+		if c.anonymous() && (isNotFound(err) || isForbidden(err)) {
+			return storage.BlobListResponse{}, nil, fmt.Errorf("failed to list blobs anonymously, the container must allow public container access: %s", err)
+		}
+		return storage.BlobListResponse{}, nil, err
 	}
 
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(c.container)
-	blob := cnt.GetBlobReference(blobName)
+	return blobListResponse(response)
+}
 
-	exists, err := blob.Exists()
+func (c Client) GetBlobSizeInBytes(blobName string, snapshot time.Time) (int64, error) {
+	err := c.requireSASPermission(sasPermissionRead)
 	if err != nil {
 		return 0, err
 	}
 
-	if !exists {
-		return 0, fmt.Errorf("%q doesn't exist", blobName)
-	}
-
-	var snapshotPtr *time.Time
-	if !snapshot.IsZero() {
-		snapshotPtr = &snapshot
+	blobURL, err := c.blobURL(blobName, &snapshot, 0)
+	if err != nil {
+		return 0, err
 	}
 
-	err = blob.GetProperties(&storage.GetBlobPropertiesOptions{
-		Snapshot: snapshotPtr,
-	})
+	properties, err := blobURL.GetProperties(context.Background(), azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
 	if err != nil {
+		if isNotFound(err) {
+			return 0, fmt.Errorf("%q doesn't exist", blobName)
+		}
 		return 0, err
 	}
 
-	return blob.Properties.ContentLength, nil
-
+	return properties.ContentLength(), nil
 }
 
 func (c Client) Get(blobName string, snapshot time.Time) ([]byte, error) {
-	client, err := storage.NewClient(c.storageAccountName, c.storageAccountKey, c.baseURL, storage.DefaultAPIVersion, true)
+	err := c.requireSASPermission(sasPermissionRead)
 	if err != nil {
 		return []byte{}, err
 	}
 
-	var snapshotPtr *time.Time
-	if !snapshot.IsZero() {
-		snapshotPtr = &snapshot
+	blobURL, err := c.blobURL(blobName, &snapshot, 0)
+	if err != nil {
+		return []byte{}, err
 	}
 
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(c.container)
-	blob := cnt.GetBlobReference(blobName)
-	blobReader, err := blob.Get(&storage.GetBlobOptions{
-		Snapshot: snapshotPtr,
-	})
+	ctx := context.Background()
+	response, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
 	if err != nil {
 		return []byte{}, err
 	}
 
+	blobReader := response.Body(azblob.RetryReaderOptions{})
 	defer blobReader.Close()
 
 	data, err := ioutil.ReadAll(blobReader)
@@ -125,27 +224,16 @@ func (c Client) Get(blobName string, snapshot time.Time) ([]byte, error) {
 
 // DownloadBlobToFile download specified blobName to specified file
 func (c Client) DownloadBlobToFile(blobName string, file *os.File, snapshot *time.Time, blockSize int64, retryTryTimeout time.Duration) error {
-	u, err := url.Parse(fmt.Sprintf("https://%s.blob.%s/%s/%s",
-		c.storageAccountName, c.baseURL, c.container, blobName))
+	err := c.requireSASPermission(sasPermissionRead)
 	if err != nil {
 		return err
 	}
 
-	credential, err := azblob.NewSharedKeyCredential(c.storageAccountName, c.storageAccountKey)
+	blobURL, err := c.blobURL(blobName, snapshot, retryTryTimeout)
 	if err != nil {
 		return err
 	}
 
-	blobURL := azblob.NewBlobURL(*u, azblob.NewPipeline(credential, azblob.PipelineOptions{
-		Retry: azblob.RetryOptions{
-			TryTimeout: retryTryTimeout,
-		},
-	}))
-
-	if snapshot != nil && !snapshot.Equal(time.Time{}) {
-		blobURL = blobURL.WithSnapshot(snapshot.Format(SnapshotTimeFormat))
-	}
-
 	ctx := context.Background()
 
 	// todo: investigate use of parallelism in options and also retrying downloading of blocks sounds promising
@@ -155,59 +243,313 @@ func (c Client) DownloadBlobToFile(blobName string, file *os.File, snapshot *tim
 	})
 }
 
-// UploadFromStream adapted from https://godoc.org/github.com/Azure/azure-storage-blob-go/azblob#example-UploadStreamToBlockBlob
-func (c Client) UploadFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) error {
-	u, err := url.Parse(fmt.Sprintf("https://%s.blob.%s/%s/%s",
-		c.storageAccountName, c.baseURL, c.container, blobName))
+// DownloadBlobToFileIfUnchanged downloads blobName to file only if it still
+// has the given etag or, when etag is empty, has not been modified since
+// lastModified. It returns ErrVersionNotAvailable otherwise.
+func (c Client) DownloadBlobToFileIfUnchanged(blobName string, file *os.File, etag string, lastModified time.Time, blockSize int64, retryTryTimeout time.Duration) error {
+	err := c.requireSASPermission(sasPermissionRead)
 	if err != nil {
 		return err
 	}
 
-	credential, err := azblob.NewSharedKeyCredential(c.storageAccountName, c.storageAccountKey)
+	blobURL, err := c.blobURL(blobName, nil, retryTryTimeout)
 	if err != nil {
 		return err
 	}
 
-	blockBlobURL := azblob.NewBlockBlobURL(*u, azblob.NewPipeline(credential, azblob.PipelineOptions{
-		Retry: azblob.RetryOptions{
-			TryTimeout: retryTryTimeout,
-		},
-	}))
+	conditions := azblob.ModifiedAccessConditions{}
+	if etag != "" {
+		conditions.IfMatch = azblob.ETag(etag)
+	} else {
+		conditions.IfUnmodifiedSince = lastModified
+	}
 
-	ctx := context.Background()
+	err = azblob.DownloadBlobToFile(context.Background(), blobURL, 0, 0, file, azblob.DownloadFromBlobOptions{
+		BlockSize:        blockSize,
+		AccessConditions: azblob.BlobAccessConditions{ModifiedAccessConditions: conditions},
+	})
+	if hasStatusCode(err, http.StatusPreconditionFailed) {
+		return ErrVersionNotAvailable
+	}
 
-	_, err = azblob.UploadStreamToBlockBlob(ctx, stream, blockBlobURL,
-		azblob.UploadStreamToBlockBlobOptions{BufferSize: blockSize, MaxBuffers: 3})
+	return err
+}
 
+// UploadFromStream adapted from https://godoc.org/github.com/Azure/azure-storage-blob-go/azblob#example-UploadStreamToBlockBlob
+func (c Client) UploadFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) error {
+	_, err := c.uploadFromStream(blobName, stream, blockSize, retryTryTimeout)
 	return err
 }
 
+// UploadBlobETagFromStream uploads stream to blobName and returns the ETag
+// and last modified time the upload gave the blob. The ETag is unquoted, as
+// it appears in blob listings.
+func (c Client) UploadBlobETagFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) (string, time.Time, error) {
+	response, err := c.uploadFromStream(blobName, stream, blockSize, retryTryTimeout)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return strings.Trim(string(response.ETag()), `"`), response.LastModified().UTC(), nil
+}
+
+func (c Client) uploadFromStream(blobName string, stream io.Reader, blockSize int, retryTryTimeout time.Duration) (azblob.CommonResponse, error) {
+	if c.anonymous() {
+		return nil, errAnonymousWrite
+	}
+
+	err := c.requireSASPermission(sasPermissionWrite, sasPermissionCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	blobURL, err := c.blobURL(blobName, nil, retryTryTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	return azblob.UploadStreamToBlockBlob(ctx, stream, blobURL.ToBlockBlobURL(),
+		azblob.UploadStreamToBlockBlobOptions{BufferSize: blockSize, MaxBuffers: 3})
+}
+
 func (c Client) CreateSnapshot(blobName string) (time.Time, error) {
-	client, err := storage.NewClient(c.storageAccountName, c.storageAccountKey, c.baseURL, storage.DefaultAPIVersion, true)
+	if c.anonymous() {
+		return time.Time{}, errAnonymousWrite
+	}
+
+	err := c.requireSASPermission(sasPermissionWrite, sasPermissionCreate)
 	if err != nil {
 		return time.Time{}, err
 	}
 
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(c.container)
-	blob := cnt.GetBlobReference(blobName)
+	blobURL, err := c.blobURL(blobName, nil, 0)
+	if err != nil {
+		return time.Time{}, err
+	}
 
-	snapshot, err := blob.CreateSnapshot(&storage.SnapshotOptions{})
+	response, err := blobURL.CreateSnapshot(context.Background(), azblob.Metadata{}, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
 	if err != nil {
 		return time.Time{}, err
 	}
 
-	return *snapshot, err
+	return time.Parse(SnapshotTimeFormat, response.Snapshot())
 }
 
 func (c Client) GetBlobURL(blobName string) (string, error) {
-	client, err := storage.NewClient(c.storageAccountName, c.storageAccountKey, c.baseURL, storage.DefaultAPIVersion, true)
+	u, err := c.containerEndpoint()
 	if err != nil {
 		return "", err
 	}
 
-	blobClient := client.GetBlobService()
-	cnt := blobClient.GetContainerReference(c.container)
-	blob := cnt.GetBlobReference(blobName)
-	return blob.GetURL(), nil
+	blobURL := azblob.NewContainerURL(*u, nil).NewBlobURL(blobName).URL()
+	return blobURL.String(), nil
+}
+
+func (c Client) containerEndpoint() (*url.URL, error) {
+	u, err := c.serviceEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	containerURL := azblob.NewServiceURL(*u, nil).NewContainerURL(c.container).URL()
+	return &containerURL, nil
+}
+
+func (c Client) serviceEndpoint() (*url.URL, error) {
+	endpoint := c.blobEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.%s", c.storageAccountName, c.baseURL)
+	}
+
+	return url.Parse(endpoint)
+}
+
+func (c Client) serviceURL(retryTryTimeout time.Duration) (azblob.ServiceURL, error) {
+	u, err := c.serviceEndpoint()
+	if err != nil {
+		return azblob.ServiceURL{}, err
+	}
+
+	credential, err := c.credential()
+	if err != nil {
+		return azblob.ServiceURL{}, err
+	}
+
+	if len(c.sasToken) > 0 && c.storageAccountKey == "" {
+		u.RawQuery = c.sasToken.Encode()
+	}
+
+	options := azblob.PipelineOptions{
+		Retry: azblob.RetryOptions{
+			TryTimeout: retryTryTimeout,
+		},
+	}
+	if c.httpClient != nil {
+		options.HTTPSender = httpSender(c.httpClient)
+	}
+	if c.readFromSecondary {
+		options.Retry.RetryReadsFromSecondaryHost = secondaryHost(u)
+	}
+
+	return azblob.NewServiceURL(*u, azblob.NewPipeline(credential, options)), nil
+}
+
+func (c Client) containerURL(retryTryTimeout time.Duration) (azblob.ContainerURL, error) {
+	serviceURL, err := c.serviceURL(retryTryTimeout)
+	if err != nil {
+		return azblob.ContainerURL{}, err
+	}
+
+	return serviceURL.NewContainerURL(c.container), nil
+}
+
+func (c Client) blobURL(blobName string, snapshot *time.Time, retryTryTimeout time.Duration) (azblob.BlobURL, error) {
+	containerURL, err := c.containerURL(retryTryTimeout)
+	if err != nil {
+		return azblob.BlobURL{}, err
+	}
+
+	blobURL := containerURL.NewBlobURL(blobName)
+	if snapshot != nil && !snapshot.IsZero() {
+		blobURL = blobURL.WithSnapshot(snapshot.Format(SnapshotTimeFormat))
+	}
+
+	return blobURL, nil
+}
+
+func (c Client) credential() (azblob.Credential, error) {
+	if c.storageAccountKey != "" && c.secondaryStorageAccountKey != "" {
+		return newKeyFailoverCredential(c.storageAccountName, c.storageAccountKey, c.secondaryStorageAccountKey)
+	}
+
+	if c.storageAccountKey != "" {
+		return azblob.NewSharedKeyCredential(c.storageAccountName, c.storageAccountKey)
+	}
+
+	if len(c.sasToken) == 0 && c.tokenSource != nil {
+		token, err := c.tokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire access token: %s", err)
+		}
+
+		return azblob.NewTokenCredential(token.AccessToken, c.refreshToken), nil
+	}
+
+	// SAS tokens are carried in the URL query; without one the requests are
+	// anonymous and only succeed against containers with public access.
+	return azblob.NewAnonymousCredential(), nil
+}
+
+func (c Client) anonymous() bool {
+	return c.storageAccountKey == "" && len(c.sasToken) == 0 && c.tokenSource == nil
+}
+
+// refreshToken keeps a token credential current for long running transfers.
+// It returns the time to wait before it should be called again, or zero to
+// stop refreshing.
+func (c Client) refreshToken(credential azblob.TokenCredential) time.Duration {
+	token, err := c.tokenSource.Token()
+	if err != nil {
+		return 0
+	}
+
+	credential.SetToken(token.AccessToken)
+	return time.Until(token.ExpiresOn) - tokenRefreshMargin
+}
+
+// accountNameFromEndpoint returns the storage account addressed by a blob
+// endpoint. Path-style endpoints, as used by the storage emulator, carry the
+// account in the first path segment; all others in the first host label.
+func accountNameFromEndpoint(u *url.URL) string {
+	if isPathStyle(u) {
+		return strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)[0]
+	}
+
+	return strings.SplitN(u.Hostname(), ".", 2)[0]
+}
+
+// isPathStyle reports whether a blob endpoint carries the account in its
+// path, as emulator endpoints like http://azurite:10000/devstoreaccount1 do,
+// rather than in its host name.
+func isPathStyle(u *url.URL) bool {
+	return strings.Trim(u.Path, "/") != "" || net.ParseIP(u.Hostname()) != nil || u.Hostname() == "localhost"
+}
+
+// secondaryHost returns the host of the read-only secondary endpoint, which
+// suffixes the account name in the host with "-secondary".
+func secondaryHost(u *url.URL) string {
+	labels := strings.SplitN(u.Host, ".", 2)
+	labels[0] += "-secondary"
+	return strings.Join(labels, ".")
+}
+
+func blobListResponse(response *azblob.ListBlobsFlatSegmentResponse) (storage.BlobListResponse, []BlobState, error) {
+	blobs := []storage.Blob{}
+	states := []BlobState{}
+	for _, item := range response.Segment.BlobItems {
+		var snapshot time.Time
+		if item.Snapshot != "" {
+			var err error
+			snapshot, err = time.Parse(SnapshotTimeFormat, item.Snapshot)
+			if err != nil {
+				return storage.BlobListResponse{}, nil, err
+			}
+		}
+
+		blobs = append(blobs, storage.Blob{
+			Name:     item.Name,
+			Snapshot: snapshot,
+			Properties: storage.BlobProperties{
+				LastModified:  storage.TimeRFC1123(item.Properties.LastModified),
+				Etag:          string(item.Properties.Etag),
+				ContentLength: int64Value(item.Properties.ContentLength),
+				BlobType:      storage.BlobType(item.Properties.BlobType),
+				CopyStatus:    string(item.Properties.CopyStatus),
+				LeaseStatus:   string(item.Properties.LeaseStatus),
+				LeaseState:    string(item.Properties.LeaseState),
+			},
+			Metadata: storage.BlobMetadata(item.Metadata),
+		})
+		states = append(states, blobState(item.Properties))
+	}
+
+	var nextMarker string
+	if response.NextMarker.Val != nil {
+		nextMarker = *response.NextMarker.Val
+	}
+
+	return storage.BlobListResponse{
+		Prefix:     stringValue(response.Prefix),
+		NextMarker: nextMarker,
+		Blobs:      blobs,
+	}, states, nil
+}
+
+func isNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+func isForbidden(err error) bool {
+	return hasStatusCode(err, http.StatusForbidden)
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	storageErr, ok := err.(azblob.StorageError)
+	return ok && storageErr.Response() != nil && storageErr.Response().StatusCode == statusCode
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func int64Value(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
 }
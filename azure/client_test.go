@@ -0,0 +1,830 @@
+package azure_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure/azurefakes"
+)
+
+var _ = Describe("Client", func() {
+	Describe("NewClient", func() {
+		It("returns an error when the sas token cannot be parsed", func() {
+			_, err := azure.NewClient(azure.Config{
+				StorageAccountName: "some-account",
+				SASToken:           "sp=%zz",
+				Container:          "some-container",
+			})
+			Expect(err).To(MatchError(ContainSubstring("failed to parse sas token")))
+		})
+	})
+
+	Context("when authenticating with a sas token", func() {
+		var client azure.Client
+
+		Context("when the token does not grant list permission", func() {
+			BeforeEach(func() {
+				var err error
+				client, err = azure.NewClient(azure.Config{
+					BaseURL:            "core.windows.net",
+					StorageAccountName: "some-account",
+					SASToken:           "?sv=2020-02-10&sr=c&sp=r&sig=some-signature",
+					Container:          "some-container",
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails to list blobs", func() {
+				_, err := client.ListBlobs(storage.ListBlobsParameters{})
+				Expect(err).To(MatchError(`sas token does not grant list permission (signed permissions: "r")`))
+			})
+
+			It("fails to upload blobs", func() {
+				err := client.UploadFromStream("example.json", bytes.NewBufferString("some-data"), 1024, time.Duration(0))
+				Expect(err).To(MatchError(`sas token does not grant write or create permission (signed permissions: "r")`))
+			})
+
+			It("fails to create snapshots", func() {
+				_, err := client.CreateSnapshot("example.json")
+				Expect(err).To(MatchError(`sas token does not grant write or create permission (signed permissions: "r")`))
+			})
+		})
+
+		Context("when the token is scoped to the container", func() {
+			BeforeEach(func() {
+				var err error
+				client, err = azure.NewClient(azure.Config{
+					BaseURL:            "core.windows.net",
+					StorageAccountName: "some-account",
+					SASToken:           "sv=2020-02-10&sr=c&sp=rlf&sig=some-signature",
+					Container:          "some-container",
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails to find blobs by tags", func() {
+				_, err := client.FindBlobsByTags("env = 'prod'")
+				Expect(err).To(MatchError(`sas token must be an account sas to find blobs by tags (signed resource: "c")`))
+			})
+		})
+
+		Context("when the token does not grant read permission", func() {
+			BeforeEach(func() {
+				var err error
+				client, err = azure.NewClient(azure.Config{
+					BaseURL:            "core.windows.net",
+					StorageAccountName: "some-account",
+					SASToken:           "sv=2020-02-10&ss=b&srt=co&sp=lw&sig=some-signature",
+					Container:          "some-container",
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails to download blobs", func() {
+				_, err := client.Get("example.json", time.Time{})
+				Expect(err).To(MatchError(`sas token does not grant read permission (signed permissions: "lw")`))
+			})
+		})
+
+		Context("when the token grants the needed permissions", func() {
+			var (
+				server   *httptest.Server
+				requests []*http.Request
+			)
+
+			BeforeEach(func() {
+				requests = nil
+
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requests = append(requests, r)
+
+					switch {
+					case r.Method == http.MethodPut:
+						w.WriteHeader(http.StatusCreated)
+					case r.URL.Query().Get("comp") == "list":
+						w.Header().Set("Content-Type", "application/xml")
+						fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="some-container"><Blobs /><NextMarker /></EnumerationResults>`)
+					default:
+						fmt.Fprint(w, "some-data")
+					}
+				}))
+
+				var err error
+				client, err = azure.NewClient(azure.Config{
+					BlobEndpoint: server.URL + "/devstoreaccount1",
+					SASToken:     "?sv=2020-02-10&sr=c&sp=rwl&sig=some-signature",
+					Container:    "some-container",
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			expectSignedRequests := func() {
+				Expect(requests).NotTo(BeEmpty())
+				for _, request := range requests {
+					Expect(request.URL.Query().Get("sig")).To(Equal("some-signature"))
+					Expect(request.URL.Query().Get("sp")).To(Equal("rwl"))
+					Expect(request.URL.Query().Get("sv")).To(Equal("2020-02-10"))
+					Expect(request.Header.Get("Authorization")).To(BeEmpty())
+				}
+			}
+
+			It("adds the token to the query of list requests", func() {
+				_, err := client.ListBlobs(storage.ListBlobsParameters{Prefix: "example"})
+				Expect(err).NotTo(HaveOccurred())
+
+				expectSignedRequests()
+				Expect(requests[0].URL.Query().Get("comp")).To(Equal("list"))
+				Expect(requests[0].URL.Query().Get("prefix")).To(Equal("example"))
+			})
+
+			It("adds the token to the query of download requests", func() {
+				file, err := ioutil.TempFile("", "")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.Remove(file.Name())
+				defer file.Close()
+
+				err = client.DownloadBlobToFile("example.json", file, nil, 1024, 0)
+				Expect(err).NotTo(HaveOccurred())
+
+				expectSignedRequests()
+				Expect(requests[len(requests)-1].Method).To(Equal(http.MethodGet))
+				Expect(requests[len(requests)-1].URL.Path).To(Equal("/devstoreaccount1/some-container/example.json"))
+			})
+
+			It("adds the token to the query of upload requests", func() {
+				err := client.UploadFromStream("example.json", bytes.NewBufferString("some-data"), 1024, 0)
+				Expect(err).NotTo(HaveOccurred())
+
+				expectSignedRequests()
+				Expect(requests[0].Method).To(Equal(http.MethodPut))
+				Expect(requests[0].URL.Path).To(Equal("/devstoreaccount1/some-container/example.json"))
+			})
+		})
+
+		It("does not include the token in the blob url", func() {
+			client, err := azure.NewClient(azure.Config{
+				BaseURL:            "core.windows.net",
+				StorageAccountName: "some-account",
+				SASToken:           "sv=2020-02-10&sr=c&sp=rl&sig=some-signature",
+				Container:          "some-container",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			url, err := client.GetBlobURL("some/example.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(url).To(Equal("https://some-account.blob.core.windows.net/some-container/some/example.json"))
+		})
+	})
+
+	Context("when authenticating with a token source", func() {
+		It("returns an error when a token cannot be acquired", func() {
+			tokenSource := &azurefakes.FakeTokenSource{}
+			tokenSource.TokenReturns(azure.Token{}, errors.New("some-error"))
+
+			client, err := azure.NewClient(azure.Config{
+				BaseURL:            "core.windows.net",
+				StorageAccountName: "some-account",
+				TokenSource:        tokenSource,
+				Container:          "some-container",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.ListBlobs(storage.ListBlobsParameters{})
+			Expect(err).To(MatchError("failed to acquire access token: some-error"))
+			Expect(tokenSource.TokenCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("GetBlobURL", func() {
+		It("uses the blob endpoint when provided", func() {
+			client, err := azure.NewClient(azure.Config{
+				BaseURL:            "core.windows.net",
+				BlobEndpoint:       "https://some-endpoint.example.com",
+				StorageAccountName: "some-account",
+				StorageAccountKey:  "c29tZS1rZXk=",
+				Container:          "some-container",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			url, err := client.GetBlobURL("example.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(url).To(Equal("https://some-endpoint.example.com/some-container/example.json"))
+		})
+	})
+
+	Context("when no credentials are provided", func() {
+		var (
+			server     *httptest.Server
+			requests   []*http.Request
+			statusCode int
+			client     azure.Client
+		)
+
+		BeforeEach(func() {
+			requests = nil
+			statusCode = http.StatusOK
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r)
+				w.Header().Set("Content-Type", "application/xml")
+				w.WriteHeader(statusCode)
+				if statusCode != http.StatusOK {
+					fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><Error><Code>ResourceNotFound</Code><Message>The specified resource does not exist.</Message></Error>`)
+					return
+				}
+				fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults ContainerName="some-container">
+  <Blobs>
+    <Blob>
+      <Name>example.json</Name>
+      <Snapshot>2017-01-02T01:01:01.0000001Z</Snapshot>
+      <Properties>
+        <Last-Modified>Mon, 02 Jan 2017 01:01:01 GMT</Last-Modified>
+        <Etag>0x8D4BCC2E4835CD0</Etag>
+        <Content-Length>9</Content-Length>
+        <BlobType>BlockBlob</BlobType>
+        <CopyStatus>pending</CopyStatus>
+      </Properties>
+    </Blob>
+  </Blobs>
+  <NextMarker>some-marker</NextMarker>
+</EnumerationResults>`)
+			}))
+
+			var err error
+			client, err = azure.NewClient(azure.Config{
+				BlobEndpoint: server.URL + "/some-account",
+				Container:    "some-container",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("lists blobs anonymously", func() {
+			response, err := client.ListBlobs(storage.ListBlobsParameters{
+				Prefix: "example",
+				Include: &storage.IncludeBlobDataset{
+					Snapshots: true,
+					Copy:      true,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL.Path).To(Equal("/some-account/some-container"))
+			Expect(requests[0].URL.Query().Get("restype")).To(Equal("container"))
+			Expect(requests[0].URL.Query().Get("comp")).To(Equal("list"))
+			Expect(requests[0].URL.Query().Get("prefix")).To(Equal("example"))
+			Expect(requests[0].URL.Query().Get("include")).To(Equal("copy,snapshots"))
+			Expect(requests[0].Header.Get("Authorization")).To(BeEmpty())
+
+			Expect(response.NextMarker).To(Equal("some-marker"))
+			Expect(response.Blobs).To(HaveLen(1))
+			Expect(response.Blobs[0].Name).To(Equal("example.json"))
+			Expect(response.Blobs[0].Snapshot).To(Equal(time.Date(2017, time.January, 2, 1, 1, 1, 100, time.UTC)))
+			Expect(response.Blobs[0].Properties.ContentLength).To(Equal(int64(9)))
+			Expect(response.Blobs[0].Properties.CopyStatus).To(Equal("pending"))
+			Expect(response.Blobs[0].Properties.Etag).To(Equal("0x8D4BCC2E4835CD0"))
+		})
+
+		It("explains that listing requires public container access", func() {
+			statusCode = http.StatusNotFound
+
+			_, err := client.ListBlobs(storage.ListBlobsParameters{})
+			Expect(err).To(MatchError(ContainSubstring("failed to list blobs anonymously, the container must allow public container access")))
+		})
+
+		It("refuses to upload blobs", func() {
+			err := client.UploadFromStream("example.json", bytes.NewBufferString("some-data"), 1024, time.Duration(0))
+			Expect(err).To(MatchError("credentials are required to write blobs"))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("refuses to create snapshots", func() {
+			_, err := client.CreateSnapshot("example.json")
+			Expect(err).To(MatchError("credentials are required to write blobs"))
+			Expect(requests).To(BeEmpty())
+		})
+	})
+
+	Context("when using a path-style blob endpoint", func() {
+		var (
+			server   *httptest.Server
+			requests []*http.Request
+			client   azure.Client
+		)
+
+		BeforeEach(func() {
+			requests = nil
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r)
+				w.Header().Set("Content-Length", "42")
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			var err error
+			client, err = azure.NewClient(azure.Config{
+				BlobEndpoint:      server.URL + "/devstoreaccount1/",
+				StorageAccountKey: storage.StorageEmulatorAccountKey,
+				Container:         "some-container",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("signs requests for the account in the path", func() {
+			size, err := client.GetBlobSizeInBytes("some/example.json", time.Time{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(int64(42)))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal(http.MethodHead))
+			Expect(requests[0].URL.Path).To(Equal("/devstoreaccount1/some-container/some/example.json"))
+			Expect(requests[0].Header.Get("Authorization")).To(HavePrefix("SharedKey devstoreaccount1:"))
+		})
+
+		It("returns blob urls on the endpoint", func() {
+			url, err := client.GetBlobURL("some/example.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(url).To(Equal(server.URL + "/devstoreaccount1/some-container/some/example.json"))
+		})
+
+		It("takes the account from the path when the host is a name", func() {
+			var err error
+			client, err = azure.NewClient(azure.Config{
+				BlobEndpoint:      "http://azurite:10000/devstoreaccount1",
+				StorageAccountKey: storage.StorageEmulatorAccountKey,
+				Container:         "some-container",
+				HTTPClient: &http.Client{
+					Transport: &http.Transport{
+						DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+							return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.GetBlobSizeInBytes("some/example.json", time.Time{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Host).To(Equal("azurite:10000"))
+			Expect(requests[0].URL.Path).To(Equal("/devstoreaccount1/some-container/some/example.json"))
+			Expect(requests[0].Header.Get("Authorization")).To(HavePrefix("SharedKey devstoreaccount1:"))
+		})
+	})
+
+	Context("when a secondary storage account key is configured", func() {
+		var (
+			server         *httptest.Server
+			authorizations []string
+			bodies         []string
+			logs           *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			authorizations = nil
+			bodies = nil
+
+			logs = gbytes.NewBuffer()
+			log.SetOutput(logs)
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				authorizations = append(authorizations, r.Header.Get("Authorization"))
+				bodies = append(bodies, string(body))
+
+				if len(authorizations) == 1 {
+					w.Header().Set("x-ms-error-code", "AuthenticationFailed")
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+
+				if r.Method == http.MethodHead {
+					w.Header().Set("Content-Length", "42")
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+
+				w.WriteHeader(http.StatusCreated)
+			}))
+		})
+
+		AfterEach(func() {
+			log.SetOutput(os.Stderr)
+			server.Close()
+		})
+
+		newClient := func(secondaryStorageAccountKey string) azure.Client {
+			client, err := azure.NewClient(azure.Config{
+				BlobEndpoint:               server.URL + "/devstoreaccount1",
+				StorageAccountKey:          "cHJpbWFyeS1rZXk=",
+				SecondaryStorageAccountKey: secondaryStorageAccountKey,
+				Container:                  "some-container",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return client
+		}
+
+		It("retries with the secondary key when the primary key is rejected", func() {
+			size, err := newClient("c2Vjb25kYXJ5LWtleQ==").GetBlobSizeInBytes("some/example.json", time.Time{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(int64(42)))
+
+			Expect(authorizations).To(HaveLen(2))
+			Expect(authorizations[0]).To(HavePrefix("SharedKey devstoreaccount1:"))
+			Expect(authorizations[1]).To(HavePrefix("SharedKey devstoreaccount1:"))
+			Expect(authorizations[1]).NotTo(Equal(authorizations[0]))
+
+			Expect(logs).To(gbytes.Say("retrying with the secondary storage account key"))
+		})
+
+		It("resends the request body with the secondary key", func() {
+			err := newClient("c2Vjb25kYXJ5LWtleQ==").UploadFromStream("some/example.json", bytes.NewBufferString("some-content"), 1024, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bodies[0]).To(Equal("some-content"))
+			Expect(bodies[1]).To(Equal("some-content"))
+		})
+
+		It("only uses the primary key when no secondary key is configured", func() {
+			_, err := newClient("").GetBlobSizeInBytes("some/example.json", time.Time{})
+			Expect(err).To(HaveOccurred())
+
+			Expect(authorizations).To(HaveLen(1))
+			Expect(logs.Contents()).To(BeEmpty())
+		})
+	})
+
+	Context("when reading from the secondary endpoint", func() {
+		var (
+			server *httptest.Server
+			hosts  []string
+			client azure.Client
+		)
+
+		BeforeEach(func() {
+			hosts = nil
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hosts = append(hosts, r.Host)
+
+				if r.Host == "some-account.blob.example.com" {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+
+				w.Header().Set("Content-Length", "42")
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			// Resolve every host to the test server so requests keep their
+			// primary and secondary host names.
+			httpClient := &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+						return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+					},
+				},
+			}
+
+			var err error
+			client, err = azure.NewClient(azure.Config{
+				BlobEndpoint:      "http://some-account.blob.example.com",
+				StorageAccountKey: "c29tZS1rZXk=",
+				Container:         "some-container",
+				HTTPClient:        httpClient,
+				ReadFromSecondary: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("falls back to the secondary endpoint when the primary is unavailable", func() {
+			size, err := client.GetBlobSizeInBytes("some/example.json", time.Time{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(int64(42)))
+
+			Expect(hosts).To(Equal([]string{
+				"some-account.blob.example.com",
+				"some-account-secondary.blob.example.com",
+			}))
+		})
+
+		It("returns an error for a path-style blob endpoint", func() {
+			_, err := azure.NewClient(azure.Config{
+				BlobEndpoint:      "http://127.0.0.1:10000/devstoreaccount1",
+				Container:         "some-container",
+				ReadFromSecondary: true,
+			})
+			Expect(err).To(MatchError(`reading from the secondary endpoint requires a blob endpoint with the account in the host name: "http://127.0.0.1:10000/devstoreaccount1"`))
+
+			_, err = azure.NewClient(azure.Config{
+				BlobEndpoint:      "http://azurite:10000/devstoreaccount1",
+				Container:         "some-container",
+				ReadFromSecondary: true,
+			})
+			Expect(err).To(MatchError(`reading from the secondary endpoint requires a blob endpoint with the account in the host name: "http://azurite:10000/devstoreaccount1"`))
+		})
+	})
+
+	Context("when using a storage account key", func() {
+		var (
+			server   *httptest.Server
+			requests []*http.Request
+			client   azure.Client
+		)
+
+		BeforeEach(func() {
+			requests = nil
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r)
+
+				switch {
+				case r.URL.Path == "/devstoreaccount1/some-container/missing.json":
+					w.Header().Set("x-ms-error-code", "BlobNotFound")
+					w.WriteHeader(http.StatusNotFound)
+				case r.Method == http.MethodPut && r.URL.Query().Get("comp") == "snapshot":
+					w.Header().Set("x-ms-snapshot", "2017-01-03T01:01:01.0000001Z")
+					w.WriteHeader(http.StatusCreated)
+				case r.Method == http.MethodHead:
+					w.Header().Set("Content-Length", "9")
+				case r.URL.Query().Get("comp") == "list" && r.URL.Query().Get("marker") == "":
+					w.Header().Set("Content-Type", "application/xml")
+					fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults ContainerName="some-container">
+  <Prefix>example</Prefix>
+  <Blobs>
+    <Blob>
+      <Name>example.json</Name>
+      <Snapshot>2017-01-02T01:01:01.0000001Z</Snapshot>
+      <Properties>
+        <Last-Modified>Mon, 02 Jan 2017 01:01:01 GMT</Last-Modified>
+        <Etag>0x8D4BCC2E4835CD0</Etag>
+        <Content-Length>9</Content-Length>
+        <BlobType>BlockBlob</BlobType>
+        <CopyStatus>success</CopyStatus>
+        <LeaseStatus>locked</LeaseStatus>
+        <LeaseState>leased</LeaseState>
+      </Properties>
+      <Metadata><version>1.2.0</version></Metadata>
+    </Blob>
+  </Blobs>
+  <NextMarker>page-2</NextMarker>
+</EnumerationResults>`)
+				case r.URL.Query().Get("comp") == "list":
+					w.Header().Set("Content-Type", "application/xml")
+					fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="some-container"><Blobs /><NextMarker /></EnumerationResults>`)
+				default:
+					fmt.Fprint(w, "some-data")
+				}
+			}))
+
+			var err error
+			client, err = azure.NewClient(azure.Config{
+				BlobEndpoint:      server.URL + "/devstoreaccount1",
+				StorageAccountKey: "c29tZS1rZXk=",
+				Container:         "some-container",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("lists blobs with their properties and metadata", func() {
+			response, err := client.ListBlobs(storage.ListBlobsParameters{
+				Prefix:     "example",
+				MaxResults: 10,
+				Include: &storage.IncludeBlobDataset{
+					Snapshots: true,
+					Metadata:  true,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL.Query().Get("prefix")).To(Equal("example"))
+			Expect(requests[0].URL.Query().Get("maxresults")).To(Equal("10"))
+			Expect(requests[0].URL.Query().Get("include")).To(Equal("metadata,snapshots"))
+			Expect(requests[0].Header.Get("Authorization")).To(HavePrefix("SharedKey devstoreaccount1:"))
+
+			Expect(response.Prefix).To(Equal("example"))
+			Expect(response.NextMarker).To(Equal("page-2"))
+			Expect(response.Blobs).To(HaveLen(1))
+
+			blob := response.Blobs[0]
+			Expect(blob.Name).To(Equal("example.json"))
+			Expect(blob.Snapshot).To(Equal(time.Date(2017, time.January, 2, 1, 1, 1, 100, time.UTC)))
+			Expect(time.Time(blob.Properties.LastModified)).To(BeTemporally("==", time.Date(2017, time.January, 2, 1, 1, 1, 0, time.UTC)))
+			Expect(blob.Properties.Etag).To(Equal("0x8D4BCC2E4835CD0"))
+			Expect(blob.Properties.ContentLength).To(Equal(int64(9)))
+			Expect(blob.Properties.BlobType).To(Equal(storage.BlobTypeBlock))
+			Expect(blob.Properties.CopyStatus).To(Equal("success"))
+			Expect(blob.Properties.LeaseStatus).To(Equal("locked"))
+			Expect(blob.Properties.LeaseState).To(Equal("leased"))
+			Expect(blob.Metadata).To(Equal(storage.BlobMetadata{"version": "1.2.0"}))
+		})
+
+		It("lists the page after the marker", func() {
+			response, err := client.ListBlobs(storage.ListBlobsParameters{Marker: "page-2"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].URL.Query().Get("marker")).To(Equal("page-2"))
+			Expect(response.Blobs).To(BeEmpty())
+			Expect(response.NextMarker).To(BeEmpty())
+		})
+
+		It("gets the size of a blob snapshot", func() {
+			size, err := client.GetBlobSizeInBytes("example.json", time.Date(2017, time.January, 2, 1, 1, 1, 100, time.UTC))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(int64(9)))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal(http.MethodHead))
+			Expect(requests[0].URL.Query().Get("snapshot")).To(Equal("2017-01-02T01:01:01.0000001Z"))
+		})
+
+		It("explains that a blob whose size is requested does not exist", func() {
+			_, err := client.GetBlobSizeInBytes("missing.json", time.Time{})
+			Expect(err).To(MatchError(`"missing.json" doesn't exist`))
+		})
+
+		It("gets the content of a blob", func() {
+			data, err := client.Get("example.json", time.Time{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("some-data"))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal(http.MethodGet))
+			Expect(requests[0].URL.Path).To(Equal("/devstoreaccount1/some-container/example.json"))
+			Expect(requests[0].URL.Query().Get("snapshot")).To(BeEmpty())
+		})
+
+		It("creates a snapshot and returns its time", func() {
+			snapshot, err := client.CreateSnapshot("example.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot).To(Equal(time.Date(2017, time.January, 3, 1, 1, 1, 100, time.UTC)))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal(http.MethodPut))
+			Expect(requests[0].URL.Query().Get("comp")).To(Equal("snapshot"))
+		})
+
+		It("returns the url of a blob", func() {
+			url, err := client.GetBlobURL("some/example.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(url).To(Equal(server.URL + "/devstoreaccount1/some-container/some/example.json"))
+			Expect(requests).To(BeEmpty())
+		})
+	})
+
+	Describe("DownloadBlobToFileIfUnchanged", func() {
+		var (
+			server     *httptest.Server
+			requests   []*http.Request
+			statusCode int
+			client     azure.Client
+			file       *os.File
+		)
+
+		BeforeEach(func() {
+			requests = nil
+			statusCode = http.StatusOK
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r)
+				if statusCode != http.StatusOK {
+					w.Header().Set("Content-Type", "application/xml")
+					w.Header().Set("x-ms-error-code", "ConditionNotMet")
+					w.WriteHeader(statusCode)
+					return
+				}
+				fmt.Fprint(w, "some-data")
+			}))
+
+			var err error
+			client, err = azure.NewClient(azure.Config{
+				BlobEndpoint:      server.URL + "/devstoreaccount1",
+				StorageAccountKey: "c29tZS1rZXk=",
+				Container:         "some-container",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			file, err = ioutil.TempFile("", "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+			file.Close()
+			os.Remove(file.Name())
+		})
+
+		It("downloads the blob only if it still has the etag", func() {
+			err := client.DownloadBlobToFileIfUnchanged("example.json", file, "0x8D4BCC2E4835CD0", time.Time{}, 1024, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).NotTo(BeEmpty())
+			for _, request := range requests {
+				Expect(request.Header.Get("If-Match")).To(Equal("0x8D4BCC2E4835CD0"))
+				Expect(request.Header.Get("If-Unmodified-Since")).To(BeEmpty())
+			}
+
+			content, err := ioutil.ReadFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("some-data"))
+		})
+
+		It("downloads the blob only if it has not been modified since the last modified time", func() {
+			lastModified := time.Date(2017, time.January, 2, 1, 1, 1, 0, time.UTC)
+
+			err := client.DownloadBlobToFileIfUnchanged("example.json", file, "", lastModified, 1024, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).NotTo(BeEmpty())
+			for _, request := range requests {
+				Expect(request.Header.Get("If-Unmodified-Since")).To(Equal("Mon, 02 Jan 2017 01:01:01 GMT"))
+				Expect(request.Header.Get("If-Match")).To(BeEmpty())
+			}
+		})
+
+		It("returns ErrVersionNotAvailable when the blob has been overwritten", func() {
+			statusCode = http.StatusPreconditionFailed
+
+			err := client.DownloadBlobToFileIfUnchanged("example.json", file, "0x8D4BCC2E4835CD0", time.Time{}, 1024, 0)
+			Expect(err).To(MatchError(azure.ErrVersionNotAvailable))
+		})
+	})
+
+	Describe("UploadBlobETagFromStream", func() {
+		It("returns the etag and last modified time from the upload response", func() {
+			var requests []*http.Request
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r)
+				w.Header().Set("ETag", `"0x8D4BCC2E4835CD0"`)
+				w.Header().Set("Last-Modified", "Mon, 02 Jan 2017 01:01:01 GMT")
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			client, err := azure.NewClient(azure.Config{
+				BlobEndpoint:      server.URL + "/devstoreaccount1",
+				StorageAccountKey: "c29tZS1rZXk=",
+				Container:         "some-container",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			etag, lastModified, err := client.UploadBlobETagFromStream("example.json", bytes.NewBufferString("some-data"), 1024, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(etag).To(Equal("0x8D4BCC2E4835CD0"))
+			Expect(lastModified).To(Equal(time.Date(2017, time.January, 2, 1, 1, 1, 0, time.UTC)))
+
+			Expect(requests).NotTo(BeEmpty())
+			for _, request := range requests {
+				Expect(request.Method).To(Equal(http.MethodPut))
+			}
+		})
+	})
+
+	It("returns an error for a blob endpoint that is not an http url", func() {
+		_, err := azure.NewClient(azure.Config{
+			BlobEndpoint: "ftp://127.0.0.1/devstoreaccount1",
+			Container:    "some-container",
+		})
+		Expect(err).To(MatchError(`blob endpoint must be an http or https url: "ftp://127.0.0.1/devstoreaccount1"`))
+	})
+})
@@ -0,0 +1,82 @@
+package azure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+const (
+	EmulatorBlobEndpoint = "http://127.0.0.1:10000/devstoreaccount1"
+)
+
+// ConnectionString holds the blob service settings of an Azure Storage
+// connection string.
+type ConnectionString struct {
+	AccountName           string
+	AccountKey            string
+	SharedAccessSignature string
+	BlobEndpoint          string
+	EndpointSuffix        string
+}
+
+// ParseConnectionString parses a connection string of the form
+// "AccountName=...;AccountKey=...;EndpointSuffix=...". When no BlobEndpoint
+// is given and DefaultEndpointsProtocol is http, the endpoint is derived
+// from the account name and endpoint suffix. UseDevelopmentStorage=true
+// resolves to the well-known storage emulator account.
+func ParseConnectionString(connectionString string) (ConnectionString, error) {
+	parts := map[string]string{}
+	for _, segment := range strings.Split(connectionString, ";") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+
+		index := strings.IndexByte(segment, '=')
+		if index <= 0 {
+			return ConnectionString{}, fmt.Errorf("invalid connection string segment: %q", segment)
+		}
+
+		key := strings.ToLower(strings.TrimSpace(segment[:index]))
+		parts[key] = strings.TrimSpace(segment[index+1:])
+	}
+
+	if strings.EqualFold(parts["usedevelopmentstorage"], "true") {
+		return ConnectionString{
+			AccountName:  storage.StorageEmulatorAccountName,
+			AccountKey:   storage.StorageEmulatorAccountKey,
+			BlobEndpoint: EmulatorBlobEndpoint,
+		}, nil
+	}
+
+	cs := ConnectionString{
+		AccountName:           parts["accountname"],
+		AccountKey:            parts["accountkey"],
+		SharedAccessSignature: parts["sharedaccesssignature"],
+		BlobEndpoint:          strings.TrimSuffix(parts["blobendpoint"], "/"),
+		EndpointSuffix:        parts["endpointsuffix"],
+	}
+
+	if cs.BlobEndpoint == "" {
+		if cs.AccountName == "" {
+			return ConnectionString{}, errors.New("connection string must contain AccountName or BlobEndpoint")
+		}
+
+		if strings.EqualFold(parts["defaultendpointsprotocol"], "http") {
+			suffix := cs.EndpointSuffix
+			if suffix == "" {
+				suffix = storage.DefaultBaseURL
+			}
+			cs.BlobEndpoint = fmt.Sprintf("http://%s.blob.%s", cs.AccountName, suffix)
+		}
+	}
+
+	if cs.AccountKey == "" && cs.SharedAccessSignature == "" {
+		return ConnectionString{}, errors.New("connection string must contain AccountKey or SharedAccessSignature")
+	}
+
+	return cs, nil
+}
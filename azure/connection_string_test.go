@@ -0,0 +1,64 @@
+package azure_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+)
+
+var _ = Describe("ParseConnectionString", func() {
+	It("parses an account key connection string", func() {
+		cs, err := azure.ParseConnectionString("DefaultEndpointsProtocol=https;AccountName=someaccount;AccountKey=c29tZS1rZXk=;EndpointSuffix=core.chinacloudapi.cn")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cs).To(Equal(azure.ConnectionString{
+			AccountName:    "someaccount",
+			AccountKey:     "c29tZS1rZXk=",
+			EndpointSuffix: "core.chinacloudapi.cn",
+		}))
+	})
+
+	It("parses a shared access signature connection string", func() {
+		cs, err := azure.ParseConnectionString("BlobEndpoint=https://someaccount.blob.core.windows.net/;SharedAccessSignature=sv=2020-02-10&ss=b&srt=co&sp=rl&sig=some%2Bsignature%3D")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cs).To(Equal(azure.ConnectionString{
+			SharedAccessSignature: "sv=2020-02-10&ss=b&srt=co&sp=rl&sig=some%2Bsignature%3D",
+			BlobEndpoint:          "https://someaccount.blob.core.windows.net",
+		}))
+	})
+
+	It("treats keys case insensitively and ignores empty segments", func() {
+		cs, err := azure.ParseConnectionString(" accountname=someaccount ;ACCOUNTKEY=c29tZS1rZXk=;;")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cs.AccountName).To(Equal("someaccount"))
+		Expect(cs.AccountKey).To(Equal("c29tZS1rZXk="))
+	})
+
+	It("derives an http blob endpoint from the protocol", func() {
+		cs, err := azure.ParseConnectionString("DefaultEndpointsProtocol=http;AccountName=someaccount;AccountKey=c29tZS1rZXk=")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cs.BlobEndpoint).To(Equal("http://someaccount.blob.core.windows.net"))
+	})
+
+	It("resolves development storage to the emulator account", func() {
+		cs, err := azure.ParseConnectionString("UseDevelopmentStorage=true")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cs.AccountName).To(Equal("devstoreaccount1"))
+		Expect(cs.AccountKey).NotTo(BeEmpty())
+		Expect(cs.BlobEndpoint).To(Equal("http://127.0.0.1:10000/devstoreaccount1"))
+	})
+
+	It("returns an error for a malformed segment", func() {
+		_, err := azure.ParseConnectionString("AccountName=someaccount;garbage")
+		Expect(err).To(MatchError(`invalid connection string segment: "garbage"`))
+	})
+
+	It("returns an error without an account or endpoint", func() {
+		_, err := azure.ParseConnectionString("AccountKey=c29tZS1rZXk=")
+		Expect(err).To(MatchError("connection string must contain AccountName or BlobEndpoint"))
+	})
+
+	It("returns an error without credentials", func() {
+		_, err := azure.ParseConnectionString("AccountName=someaccount")
+		Expect(err).To(MatchError("connection string must contain AccountKey or SharedAccessSignature"))
+	})
+})
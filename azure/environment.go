@@ -0,0 +1,47 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// Environment holds the endpoints of an Azure cloud used by the resource.
+type Environment struct {
+	Name                    string
+	StorageEndpointSuffix   string
+	ActiveDirectoryEndpoint string
+}
+
+var environmentNames = []string{
+	azure.PublicCloud.Name,
+	azure.ChinaCloud.Name,
+	azure.USGovernmentCloud.Name,
+	azure.GermanCloud.Name,
+}
+
+// EnvironmentFromName returns the named Azure cloud, e.g. AzureChinaCloud.
+// An empty name selects the Azure public cloud.
+func EnvironmentFromName(name string) (Environment, error) {
+	if name == "" {
+		name = azure.PublicCloud.Name
+	}
+
+	for _, environmentName := range environmentNames {
+		if strings.EqualFold(name, environmentName) {
+			environment, err := azure.EnvironmentFromName(environmentName)
+			if err != nil {
+				return Environment{}, err
+			}
+
+			return Environment{
+				Name:                    environment.Name,
+				StorageEndpointSuffix:   environment.StorageEndpointSuffix,
+				ActiveDirectoryEndpoint: environment.ActiveDirectoryEndpoint,
+			}, nil
+		}
+	}
+
+	return Environment{}, fmt.Errorf("unknown environment %q, must be one of: %s", name, strings.Join(environmentNames, ", "))
+}
@@ -0,0 +1,30 @@
+package azure_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+)
+
+var _ = Describe("EnvironmentFromName", func() {
+	DescribeTable("resolves named clouds",
+		func(name, storageEndpointSuffix, activeDirectoryEndpoint string) {
+			environment, err := azure.EnvironmentFromName(name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(environment.StorageEndpointSuffix).To(Equal(storageEndpointSuffix))
+			Expect(environment.ActiveDirectoryEndpoint).To(Equal(activeDirectoryEndpoint))
+		},
+		Entry("default", "", "core.windows.net", "https://login.microsoftonline.com/"),
+		Entry("public", "AzurePublicCloud", "core.windows.net", "https://login.microsoftonline.com/"),
+		Entry("china", "AzureChinaCloud", "core.chinacloudapi.cn", "https://login.chinacloudapi.cn/"),
+		Entry("us government", "AzureUSGovernmentCloud", "core.usgovcloudapi.net", "https://login.microsoftonline.us/"),
+		Entry("germany", "AzureGermanCloud", "core.cloudapi.de", "https://login.microsoftonline.de/"),
+		Entry("case insensitive", "azurechinacloud", "core.chinacloudapi.cn", "https://login.chinacloudapi.cn/"),
+	)
+
+	It("returns an error for an unknown cloud", func() {
+		_, err := azure.EnvironmentFromName("AzureStackCloud")
+		Expect(err).To(MatchError(`unknown environment "AzureStackCloud", must be one of: AzurePublicCloud, AzureChinaCloud, AzureUSGovernmentCloud, AzureGermanCloud`))
+	})
+})
@@ -0,0 +1,79 @@
+package azure
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	StorageScope = "https://storage.azure.com/.default"
+
+	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+type federatedTokenSource struct {
+	tokenURL   string
+	clientID   string
+	tokenFile  string
+	httpClient *http.Client
+
+	cache *tokenCache
+}
+
+// NewFederatedTokenSource returns a TokenSource that exchanges the federated
+// token in tokenFile (e.g. a projected Kubernetes service account token) for
+// a storage access token issued to clientID. The file is re-read on every
+// exchange so rotated tokens are picked up. Requests are sent with
+// httpClient, or http.DefaultClient when it is nil.
+func NewFederatedTokenSource(activeDirectoryEndpoint, tenantID, clientID, tokenFile string, httpClient *http.Client) (TokenSource, error) {
+	endpoint, err := url.Parse(activeDirectoryEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenURL, err := endpoint.Parse(fmt.Sprintf("%s/oauth2/v2.0/token", url.PathEscape(tenantID)))
+	if err != nil {
+		return nil, err
+	}
+
+	return federatedTokenSource{
+		tokenURL:   tokenURL.String(),
+		clientID:   clientID,
+		tokenFile:  tokenFile,
+		httpClient: httpClientOrDefault(httpClient),
+		cache:      &tokenCache{},
+	}, nil
+}
+
+func (s federatedTokenSource) Token() (Token, error) {
+	return s.cache.get(s.exchange)
+}
+
+func (s federatedTokenSource) exchange() (Token, error) {
+	assertion, err := ioutil.ReadFile(s.tokenFile)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to read federated token file: %s", err)
+	}
+
+	response, err := s.httpClient.PostForm(s.tokenURL, url.Values{
+		"client_id":             {s.clientID},
+		"scope":                 {StorageScope},
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {clientAssertionType},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+	})
+	if err != nil {
+		return Token{}, err
+	}
+	defer response.Body.Close()
+
+	token, err := decodeTokenResponse(response)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to exchange federated token: %s", err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,126 @@
+package azure_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+)
+
+var _ = Describe("FederatedTokenSource", func() {
+	var (
+		server     *httptest.Server
+		paths      []string
+		forms      []url.Values
+		statusCode int
+		expiresIn  int
+
+		tempDir   string
+		tokenFile string
+	)
+
+	BeforeEach(func() {
+		paths = nil
+		forms = nil
+		statusCode = http.StatusOK
+		expiresIn = 3600
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.ParseForm()).To(Succeed())
+			paths = append(paths, r.URL.Path)
+			forms = append(forms, r.PostForm)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(statusCode)
+			if statusCode != http.StatusOK {
+				fmt.Fprint(w, `{"error": "invalid_client", "error_description": "some-description"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token": "some-access-token-%d", "expires_in": %d, "token_type": "Bearer"}`, len(paths), expiresIn)
+		}))
+
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		tokenFile = filepath.Join(tempDir, "token")
+		Expect(ioutil.WriteFile(tokenFile, []byte("some-federated-token\n"), os.ModePerm)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	It("exchanges the federated token for a storage token", func() {
+		tokenSource, err := azure.NewFederatedTokenSource(server.URL, "some-tenant", "some-client-id", tokenFile, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		token, err := tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token.AccessToken).To(Equal("some-access-token-1"))
+
+		Expect(paths).To(Equal([]string{"/some-tenant/oauth2/v2.0/token"}))
+		Expect(forms[0].Get("client_id")).To(Equal("some-client-id"))
+		Expect(forms[0].Get("scope")).To(Equal("https://storage.azure.com/.default"))
+		Expect(forms[0].Get("grant_type")).To(Equal("client_credentials"))
+		Expect(forms[0].Get("client_assertion_type")).To(Equal("urn:ietf:params:oauth:client-assertion-type:jwt-bearer"))
+		Expect(forms[0].Get("client_assertion")).To(Equal("some-federated-token"))
+	})
+
+	It("reuses the token until it is about to expire", func() {
+		tokenSource, err := azure.NewFederatedTokenSource(server.URL, "some-tenant", "some-client-id", tokenFile, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+		token, err := tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token.AccessToken).To(Equal("some-access-token-1"))
+		Expect(paths).To(HaveLen(1))
+	})
+
+	It("re-reads the token file when refreshing", func() {
+		expiresIn = 60
+
+		tokenSource, err := azure.NewFederatedTokenSource(server.URL, "some-tenant", "some-client-id", tokenFile, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(ioutil.WriteFile(tokenFile, []byte("some-rotated-token"), os.ModePerm)).To(Succeed())
+
+		token, err := tokenSource.Token()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token.AccessToken).To(Equal("some-access-token-2"))
+		Expect(forms[1].Get("client_assertion")).To(Equal("some-rotated-token"))
+	})
+
+	It("returns an error when the token file cannot be read", func() {
+		tokenSource, err := azure.NewFederatedTokenSource(server.URL, "some-tenant", "some-client-id", filepath.Join(tempDir, "missing"), nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = tokenSource.Token()
+		Expect(err).To(MatchError(ContainSubstring("failed to read federated token file")))
+		Expect(paths).To(BeEmpty())
+	})
+
+	It("returns an error when the exchange is rejected", func() {
+		statusCode = http.StatusUnauthorized
+
+		tokenSource, err := azure.NewFederatedTokenSource(server.URL, "some-tenant", "some-client-id", tokenFile, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = tokenSource.Token()
+		Expect(err).To(MatchError("failed to exchange federated token: 401 Unauthorized: invalid_client some-description"))
+	})
+})
@@ -0,0 +1,100 @@
+package azure
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// HTTPConfig describes how requests to Azure leave the worker. ProxyURL and
+// NoProxy override the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment
+// variables; the instance metadata service is never proxied. CACerts holds
+// PEM encoded certificates trusted in addition to the system roots.
+type HTTPConfig struct {
+	ProxyURL string
+	NoProxy  string
+	CACerts  string
+}
+
+// NewHTTPClient returns an http.Client for the given configuration, or nil
+// when the configuration is empty so that the defaults apply.
+func NewHTTPClient(config HTTPConfig) (*http.Client, error) {
+	if config == (HTTPConfig{}) {
+		return nil, nil
+	}
+
+	proxyConfig := httpproxy.FromEnvironment()
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy url: %s", err)
+		}
+
+		if proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("proxy url must be an absolute url: %q", config.ProxyURL)
+		}
+
+		proxyConfig.HTTPProxy = config.ProxyURL
+		proxyConfig.HTTPSProxy = config.ProxyURL
+	}
+
+	if config.NoProxy != "" {
+		proxyConfig.NoProxy = config.NoProxy
+	}
+
+	// The instance metadata service cannot be reached through a proxy.
+	if proxyConfig.NoProxy == "" {
+		proxyConfig.NoProxy = imdsHost
+	} else {
+		proxyConfig.NoProxy += "," + imdsHost
+	}
+
+	proxy := proxyConfig.ProxyFunc()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(request *http.Request) (*url.URL, error) {
+		return proxy(request.URL)
+	}
+
+	if config.CACerts != "" {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+
+		if !rootCAs.AppendCertsFromPEM([]byte(config.CACerts)) {
+			return nil, errors.New("ca certs do not contain any PEM encoded certificates")
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// httpSender sends the requests of an azblob pipeline with httpClient.
+func httpSender(httpClient *http.Client) pipeline.Factory {
+	return pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			response, err := httpClient.Do(request.WithContext(ctx))
+			if err != nil {
+				err = pipeline.NewError(err, "HTTP request failed")
+			}
+			return pipeline.NewHTTPResponse(response), err
+		}
+	})
+}
+
+func httpClientOrDefault(httpClient *http.Client) *http.Client {
+	if httpClient == nil {
+		return http.DefaultClient
+	}
+	return httpClient
+}
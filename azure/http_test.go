@@ -0,0 +1,144 @@
+package azure_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+)
+
+var _ = Describe("NewHTTPClient", func() {
+	It("leaves the defaults in place when nothing is configured", func() {
+		httpClient, err := azure.NewHTTPClient(azure.HTTPConfig{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(httpClient).To(BeNil())
+	})
+
+	Context("when a proxy url is provided", func() {
+		var (
+			proxy    *httptest.Server
+			requests []*http.Request
+		)
+
+		BeforeEach(func() {
+			requests = nil
+
+			proxy = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r)
+				w.Header().Set("Content-Length", "42")
+				w.WriteHeader(http.StatusOK)
+			}))
+		})
+
+		AfterEach(func() {
+			proxy.Close()
+		})
+
+		It("sends blob requests through the proxy", func() {
+			httpClient, err := azure.NewHTTPClient(azure.HTTPConfig{ProxyURL: proxy.URL})
+			Expect(err).NotTo(HaveOccurred())
+
+			client, err := azure.NewClient(azure.Config{
+				BlobEndpoint:      "http://some-account.blob.example.com",
+				StorageAccountKey: "c29tZS1rZXk=",
+				Container:         "some-container",
+				HTTPClient:        httpClient,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			size, err := client.GetBlobSizeInBytes("some/example.json", time.Time{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(int64(42)))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Host).To(Equal("some-account.blob.example.com"))
+			Expect(requests[0].URL.Path).To(Equal("/some-container/some/example.json"))
+		})
+
+		It("bypasses the proxy for hosts matching no proxy", func() {
+			httpClient, err := azure.NewHTTPClient(azure.HTTPConfig{
+				ProxyURL: proxy.URL,
+				NoProxy:  ".example.com",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			transport := httpClient.Transport.(*http.Transport)
+
+			proxied, err := transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "some-account.blob.example.com"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proxied).To(BeNil())
+
+			proxied, err = transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "login.microsoftonline.com"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proxied.String()).To(Equal(proxy.URL))
+		})
+
+		It("bypasses the proxy for the instance metadata service", func() {
+			for _, noProxy := range []string{"", ".example.com"} {
+				httpClient, err := azure.NewHTTPClient(azure.HTTPConfig{
+					ProxyURL: proxy.URL,
+					NoProxy:  noProxy,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				transport := httpClient.Transport.(*http.Transport)
+
+				imdsURL, err := url.Parse(azure.DefaultIMDSEndpoint)
+				Expect(err).NotTo(HaveOccurred())
+
+				proxied, err := transport.Proxy(&http.Request{URL: imdsURL})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(proxied).To(BeNil())
+			}
+		})
+	})
+
+	It("returns an error for a relative proxy url", func() {
+		_, err := azure.NewHTTPClient(azure.HTTPConfig{ProxyURL: "proxy.example.com"})
+		Expect(err).To(MatchError(`proxy url must be an absolute url: "proxy.example.com"`))
+	})
+
+	Context("when ca certs are provided", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("trusts servers signed by them", func() {
+			caCerts := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+			httpClient, err := azure.NewHTTPClient(azure.HTTPConfig{CACerts: string(caCerts)})
+			Expect(err).NotTo(HaveOccurred())
+
+			response, err := httpClient.Get(server.URL)
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("does not trust other servers", func() {
+			httpClient, err := azure.NewHTTPClient(azure.HTTPConfig{NoProxy: "*"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = httpClient.Get(server.URL)
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+		})
+
+		It("returns an error when they contain no certificates", func() {
+			_, err := azure.NewHTTPClient(azure.HTTPConfig{CACerts: "not-a-certificate"})
+			Expect(err).To(MatchError("ca certs do not contain any PEM encoded certificates"))
+		})
+	})
+})
@@ -0,0 +1,89 @@
+package azure
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// keyFailoverCredential signs requests with the primary storage account key
+// and, when the service rejects the signature, signs them again with the
+// secondary key. Once the primary key has failed the secondary key is used
+// for the remaining requests of the pipeline.
+type keyFailoverCredential struct {
+	*azblob.SharedKeyCredential
+	secondary *azblob.SharedKeyCredential
+
+	failover *keyFailover
+}
+
+type keyFailover struct {
+	mutex  sync.Mutex
+	failed bool
+}
+
+func newKeyFailoverCredential(accountName, primaryKey, secondaryKey string) (azblob.Credential, error) {
+	primary, err := azblob.NewSharedKeyCredential(accountName, primaryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	secondary, err := azblob.NewSharedKeyCredential(accountName, secondaryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return keyFailoverCredential{
+		SharedKeyCredential: primary,
+		secondary:           secondary,
+		failover:            &keyFailover{},
+	}, nil
+}
+
+func (f keyFailoverCredential) New(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.Policy {
+	primary := f.SharedKeyCredential.New(next, po)
+	secondary := f.secondary.New(next, po)
+
+	return pipeline.PolicyFunc(func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+		if f.failover.primaryFailed() {
+			return secondary.Do(ctx, request)
+		}
+
+		response, err := primary.Do(ctx, request)
+		if !isAuthenticationFailed(err) {
+			return response, err
+		}
+
+		if rewindErr := request.RewindBody(); rewindErr != nil {
+			return response, err
+		}
+
+		f.failover.fail()
+		return secondary.Do(ctx, request)
+	})
+}
+
+func (f *keyFailover) primaryFailed() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.failed
+}
+
+func (f *keyFailover) fail() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.failed {
+		log.Println("authentication with the storage account key failed, retrying with the secondary storage account key")
+	}
+	f.failed = true
+}
+
+func isAuthenticationFailed(err error) bool {
+	storageErr, ok := err.(azblob.StorageError)
+	return ok && storageErr.ServiceCode() == azblob.ServiceCodeAuthenticationFailed
+}
@@ -0,0 +1,82 @@
+package azure
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	DefaultIMDSEndpoint = "http://" + imdsHost + "/metadata/identity/oauth2/token"
+
+	// imdsHost is the link-local address of the instance metadata service,
+	// which is only reachable from the host itself.
+	imdsHost = "169.254.169.254"
+
+	imdsAPIVersion = "2018-02-01"
+)
+
+type managedIdentityTokenSource struct {
+	endpoint   *url.URL
+	httpClient *http.Client
+
+	cache *tokenCache
+}
+
+// NewManagedIdentityTokenSource returns a TokenSource that requests tokens for
+// the managed identity assigned to the host from the instance metadata
+// endpoint. The system assigned identity is used when clientID is empty.
+// The endpoint is only reachable from the host, so requests never go through
+// a proxy, whether configured or taken from the environment.
+func NewManagedIdentityTokenSource(imdsEndpoint, clientID string) (TokenSource, error) {
+	endpoint, err := url.Parse(imdsEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	query := endpoint.Query()
+	query.Set("api-version", imdsAPIVersion)
+	query.Set("resource", StorageResource)
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	endpoint.RawQuery = query.Encode()
+
+	return managedIdentityTokenSource{
+		endpoint:   endpoint,
+		httpClient: unproxiedHTTPClient(),
+		cache:      &tokenCache{},
+	}, nil
+}
+
+func (s managedIdentityTokenSource) Token() (Token, error) {
+	return s.cache.get(s.request)
+}
+
+func (s managedIdentityTokenSource) request() (Token, error) {
+	request, err := http.NewRequest(http.MethodGet, s.endpoint.String(), nil)
+	if err != nil {
+		return Token{}, err
+	}
+	request.Header.Set("Metadata", "true")
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to request managed identity token: %s", err)
+	}
+	defer response.Body.Close()
+
+	token, err := decodeTokenResponse(response)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to request managed identity token: %s", err)
+	}
+
+	return token, nil
+}
+
+func unproxiedHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+
+	return &http.Client{Transport: transport}
+}
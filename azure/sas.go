@@ -0,0 +1,61 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+)
+
+type sasPermission struct {
+	flag string
+	name string
+}
+
+var (
+	sasPermissionRead   = sasPermission{flag: "r", name: "read"}
+	sasPermissionWrite  = sasPermission{flag: "w", name: "write"}
+	sasPermissionCreate = sasPermission{flag: "c", name: "create"}
+	sasPermissionList   = sasPermission{flag: "l", name: "list"}
+	sasPermissionTags   = sasPermission{flag: "t", name: "tags"}
+	sasPermissionFilter = sasPermission{flag: "f", name: "filter"}
+)
+
+// requireSASPermission returns an error when the client authenticates with a
+// SAS token whose signed permissions grant none of the given permissions. A
+// token without signed permissions (e.g. one tied to a stored access policy)
+// is passed through and left for the service to reject.
+func (c Client) requireSASPermission(permissions ...sasPermission) error {
+	if c.storageAccountKey != "" || len(c.sasToken) == 0 {
+		return nil
+	}
+
+	signed := c.sasToken.Get("sp")
+	if signed == "" {
+		return nil
+	}
+
+	var names []string
+	for _, permission := range permissions {
+		if strings.Contains(signed, permission.flag) {
+			return nil
+		}
+		names = append(names, permission.name)
+	}
+
+	return fmt.Errorf("sas token does not grant %s permission (signed permissions: %q)", strings.Join(names, " or "), signed)
+}
+
+// requireAccountSAS returns an error when the client authenticates with a
+// service SAS token, which is scoped to a container or blob and so cannot
+// authorize calls on the blob service itself.
+func (c Client) requireAccountSAS() error {
+	if c.storageAccountKey != "" || len(c.sasToken) == 0 {
+		return nil
+	}
+
+	resource := c.sasToken.Get("sr")
+	if resource == "" {
+		return nil
+	}
+
+	return fmt.Errorf("sas token must be an account sas to find blobs by tags (signed resource: %q)", resource)
+}
@@ -0,0 +1,200 @@
+package azure
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/adal"
+)
+
+const (
+	StorageResource = "https://storage.azure.com/"
+
+	tokenRefreshMargin = 2 * time.Minute
+	tokenRefreshWithin = 5 * time.Minute
+)
+
+type Token struct {
+	AccessToken string
+	ExpiresOn   time.Time
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . TokenSource
+
+// TokenSource acquires OAuth bearer tokens for the Azure Storage resource.
+type TokenSource interface {
+	Token() (Token, error)
+}
+
+type servicePrincipalTokenSource struct {
+	spt *adal.ServicePrincipalToken
+}
+
+// NewClientSecretTokenSource returns a TokenSource that authenticates a
+// service principal with a client secret against the given Azure AD endpoint.
+// Requests are sent with httpClient, or http.DefaultClient when it is nil.
+func NewClientSecretTokenSource(activeDirectoryEndpoint, tenantID, clientID, clientSecret string, httpClient *http.Client) (TokenSource, error) {
+	oauthConfig, err := adal.NewOAuthConfig(activeDirectoryEndpoint, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	spt, err := adal.NewServicePrincipalToken(*oauthConfig, clientID, clientSecret, StorageResource)
+	if err != nil {
+		return nil, err
+	}
+	spt.SetSender(httpClientOrDefault(httpClient))
+
+	return servicePrincipalTokenSource{spt: spt}, nil
+}
+
+// NewClientCertificateTokenSource returns a TokenSource that authenticates a
+// service principal with a PEM encoded certificate and RSA private key
+// against the given Azure AD endpoint. Requests are sent with httpClient, or
+// http.DefaultClient when it is nil.
+func NewClientCertificateTokenSource(activeDirectoryEndpoint, tenantID, clientID string, certificatePEM []byte, httpClient *http.Client) (TokenSource, error) {
+	certificate, privateKey, err := parseClientCertificate(certificatePEM)
+	if err != nil {
+		return nil, err
+	}
+
+	oauthConfig, err := adal.NewOAuthConfig(activeDirectoryEndpoint, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	spt, err := adal.NewServicePrincipalTokenFromCertificate(*oauthConfig, clientID, certificate, privateKey, StorageResource)
+	if err != nil {
+		return nil, err
+	}
+	spt.SetSender(httpClientOrDefault(httpClient))
+
+	return servicePrincipalTokenSource{spt: spt}, nil
+}
+
+func (s servicePrincipalTokenSource) Token() (Token, error) {
+	err := s.spt.EnsureFresh()
+	if err != nil {
+		return Token{}, err
+	}
+
+	token := s.spt.Token()
+	return Token{
+		AccessToken: token.AccessToken,
+		ExpiresOn:   token.Expires(),
+	}, nil
+}
+
+// tokenCache holds the last token acquired by a TokenSource and reuses it
+// until it is about to expire.
+type tokenCache struct {
+	mutex sync.Mutex
+	token Token
+}
+
+func (c *tokenCache) get(acquire func() (Token, error)) (Token, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.token.AccessToken != "" && time.Until(c.token.ExpiresOn) > tokenRefreshWithin {
+		return c.token, nil
+	}
+
+	token, err := acquire()
+	if err != nil {
+		return Token{}, err
+	}
+
+	c.token = token
+	return c.token, nil
+}
+
+type tokenResponse struct {
+	AccessToken      string      `json:"access_token"`
+	ExpiresIn        json.Number `json:"expires_in"`
+	Error            string      `json:"error"`
+	ErrorDescription string      `json:"error_description"`
+}
+
+func decodeTokenResponse(response *http.Response) (Token, error) {
+	var body tokenResponse
+	err := json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to decode token response (%s): %s", response.Status, err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(body.Error+" "+body.ErrorDescription))
+	}
+
+	expiresIn, err := body.ExpiresIn.Int64()
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to parse token expiry: %s", err)
+	}
+
+	return Token{
+		AccessToken: body.AccessToken,
+		ExpiresOn:   time.Now().Add(time.Duration(expiresIn) * time.Second),
+	}, nil
+}
+
+func parseClientCertificate(data []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	var certificate *x509.Certificate
+	var privateKey *rsa.PrivateKey
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "CERTIFICATE":
+			if certificate != nil {
+				continue
+			}
+
+			var err error
+			certificate, err = x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse client certificate: %s", err)
+			}
+		case "RSA PRIVATE KEY":
+			var err error
+			privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse client certificate private key: %s", err)
+			}
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse client certificate private key: %s", err)
+			}
+
+			var ok bool
+			privateKey, ok = key.(*rsa.PrivateKey)
+			if !ok {
+				return nil, nil, errors.New("client certificate private key must be an RSA key")
+			}
+		}
+	}
+
+	if certificate == nil {
+		return nil, nil, errors.New("client certificate does not contain a certificate")
+	}
+
+	if privateKey == nil {
+		return nil, nil, errors.New("client certificate does not contain a private key")
+	}
+
+	return certificate, privateKey, nil
+}
@@ -0,0 +1,167 @@
+package azure_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/azure-blobstore-resource/azure"
+)
+
+var _ = Describe("TokenSource", func() {
+	var (
+		server   *httptest.Server
+		requests []*http.Request
+		forms    []url.Values
+		expires  time.Time
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		forms = nil
+		expires = time.Now().Add(time.Hour).Truncate(time.Second)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.ParseForm()).To(Succeed())
+			requests = append(requests, r)
+			forms = append(forms, r.PostForm)
+
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{
+				"access_token": "some-access-token",
+				"expires_in": "3600",
+				"expires_on": "%d",
+				"not_before": "%d",
+				"resource": "https://storage.azure.com/",
+				"token_type": "Bearer"
+			}`, expires.Unix(), time.Now().Unix())
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("NewClientSecretTokenSource", func() {
+		It("requests a storage token for the service principal", func() {
+			tokenSource, err := azure.NewClientSecretTokenSource(server.URL, "some-tenant", "some-client-id", "some-secret", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			token, err := tokenSource.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("some-access-token"))
+			Expect(token.ExpiresOn.Equal(expires)).To(BeTrue())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal(http.MethodPost))
+			Expect(requests[0].URL.Path).To(Equal("/some-tenant/oauth2/token"))
+			Expect(forms[0].Get("grant_type")).To(Equal("client_credentials"))
+			Expect(forms[0].Get("client_id")).To(Equal("some-client-id"))
+			Expect(forms[0].Get("client_secret")).To(Equal("some-secret"))
+			Expect(forms[0].Get("resource")).To(Equal("https://storage.azure.com/"))
+		})
+
+		It("reuses the token until it is about to expire", func() {
+			tokenSource, err := azure.NewClientSecretTokenSource(server.URL, "some-tenant", "some-client-id", "some-secret", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = tokenSource.Token()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = tokenSource.Token()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(1))
+		})
+	})
+
+	Describe("NewClientCertificateTokenSource", func() {
+		var certificatePEM []byte
+
+		BeforeEach(func() {
+			privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(1),
+				Subject:      pkix.Name{CommonName: "some-client"},
+				NotBefore:    time.Now(),
+				NotAfter:     time.Now().Add(time.Hour),
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			certificatePEM = append(
+				pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+				pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})...,
+			)
+		})
+
+		It("requests a storage token with a signed client assertion", func() {
+			tokenSource, err := azure.NewClientCertificateTokenSource(server.URL, "some-tenant", "some-client-id", certificatePEM, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			token, err := tokenSource.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("some-access-token"))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL.Path).To(Equal("/some-tenant/oauth2/token"))
+			Expect(forms[0].Get("client_id")).To(Equal("some-client-id"))
+			Expect(forms[0].Get("client_assertion_type")).To(Equal("urn:ietf:params:oauth:client-assertion-type:jwt-bearer"))
+			Expect(forms[0].Get("client_assertion")).NotTo(BeEmpty())
+			Expect(forms[0].Get("client_secret")).To(BeEmpty())
+		})
+
+		It("returns an error when the private key is missing", func() {
+			block, _ := pem.Decode(certificatePEM)
+			_, err := azure.NewClientCertificateTokenSource(server.URL, "some-tenant", "some-client-id", pem.EncodeToMemory(block), nil)
+			Expect(err).To(MatchError("client certificate does not contain a private key"))
+		})
+
+		It("returns an error when the certificate is missing", func() {
+			_, err := azure.NewClientCertificateTokenSource(server.URL, "some-tenant", "some-client-id", []byte("not-a-certificate"), nil)
+			Expect(err).To(MatchError("client certificate does not contain a certificate"))
+		})
+	})
+
+	Describe("NewManagedIdentityTokenSource", func() {
+		It("requests a storage token for the system assigned identity", func() {
+			tokenSource, err := azure.NewManagedIdentityTokenSource(server.URL+"/metadata/identity/oauth2/token", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			token, err := tokenSource.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("some-access-token"))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal(http.MethodGet))
+			Expect(requests[0].URL.Path).To(Equal("/metadata/identity/oauth2/token"))
+			Expect(requests[0].Header.Get("Metadata")).To(Equal("true"))
+			Expect(requests[0].URL.Query().Get("resource")).To(Equal("https://storage.azure.com/"))
+			Expect(requests[0].URL.Query().Get("api-version")).NotTo(BeEmpty())
+			Expect(requests[0].URL.Query()).NotTo(HaveKey("client_id"))
+		})
+
+		It("requests a storage token for a user assigned identity", func() {
+			tokenSource, err := azure.NewManagedIdentityTokenSource(server.URL+"/metadata/identity/oauth2/token", "some-identity-client-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = tokenSource.Token()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL.Query().Get("client_id")).To(Equal("some-identity-client-id"))
+		})
+	})
+})
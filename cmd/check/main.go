@@ -2,11 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 
-	"github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/pivotal-cf/azure-blobstore-resource/api"
 	"github.com/pivotal-cf/azure-blobstore-resource/azure"
 )
@@ -18,34 +18,89 @@ func main() {
 		log.Fatal("failed to decode: ", err)
 	}
 
-	baseURL := storage.DefaultBaseURL
-	if checkRequest.Source.BaseURL != "" {
-		baseURL = checkRequest.Source.BaseURL
+	config, err := checkRequest.Source.AzureConfig()
+	if err != nil {
+		log.Fatal("invalid source configuration: ", err)
+	}
+
+	if checkRequest.Source.MaxVersions < 0 {
+		log.Fatal("invalid source configuration: max_versions must not be negative")
 	}
 
-	azureClient := azure.NewClient(
-		baseURL,
-		checkRequest.Source.StorageAccountName,
-		checkRequest.Source.StorageAccountKey,
-		checkRequest.Source.Container,
-	)
-	check := api.NewCheck(azureClient)
+	azureClient, err := azure.NewClient(config)
+	if err != nil {
+		log.Fatal("failed to create azure client: ", err)
+	}
+	check := api.NewCheck(azureClient).WithBlobStateFilter(checkRequest.Source.BlobStateFilter())
 
 	var versions []api.Version
 	if checkRequest.Source.VersionedFile != "" {
-		versions, err = check.VersionsSince(checkRequest.Source.VersionedFile, checkRequest.Version.Snapshot)
+		versionBy, err := api.ParseVersionBy(checkRequest.Source.VersionBy)
+		if err != nil {
+			log.Fatal("invalid source configuration: ", err)
+		}
+
+		initialSnapshot, err := checkRequest.Source.InitialSnapshot()
+		if err != nil {
+			log.Fatal("invalid source configuration: ", err)
+		}
+		check = check.WithInitialSnapshot(initialSnapshot)
+
+		switch versionBy {
+		case api.VersionBySnapshot:
+			versions, err = check.VersionsSince(checkRequest.Source.VersionedFile, checkRequest.Version.Snapshot)
+		case api.VersionByVersionID:
+			versions, err = check.VersionsSinceVersionID(checkRequest.Source.VersionedFile, checkRequest.Version.VersionID)
+		default:
+			versions, err = check.LatestVersionBy(checkRequest.Source.VersionedFile, versionBy)
+		}
+		if errors.Is(err, api.ErrBlobNotFound) && checkRequest.Source.AllowMissing {
+			versions, err = []api.Version{}, nil
+		}
 		if err != nil {
 			log.Fatal("failed to get latest version: ", err)
 		}
+	} else if checkRequest.Source.TagFilter != "" {
+		options, err := checkRequest.Source.RegexpOptions()
+		if err != nil {
+			log.Fatal("invalid source configuration: ", err)
+		}
+
+		versions, err = check.VersionsSinceTagFilter(
+			checkRequest.Source.TagFilter,
+			checkRequest.Source.Regexp,
+			checkRequest.Source.VersionTag,
+			checkRequest.Version.Version,
+			options,
+		)
+		if err != nil {
+			log.Fatal("failed to get latest version from tag_filter: ", err)
+		}
 	} else if checkRequest.Source.Regexp != "" {
-		versions, err = check.VersionsSinceRegexp(checkRequest.Source.Regexp, checkRequest.Version.Version)
+		options, err := checkRequest.Source.RegexpOptions()
+		if err != nil {
+			log.Fatal("invalid source configuration: ", err)
+		}
+
+		if checkRequest.Source.VersionMetadataKey != "" {
+			versions, err = check.VersionsSinceMetadata(
+				checkRequest.Source.Regexp,
+				checkRequest.Source.VersionMetadataKey,
+				checkRequest.Version.Version,
+				options,
+			)
+		} else {
+			versions, err = check.VersionsSinceRegexp(checkRequest.Source.Regexp, checkRequest.Version.Version, options)
+		}
 		if err != nil {
 			log.Fatal("failed to get latest version from regexp: ", err)
 		}
 	} else {
-		log.Fatal("must supply either versioned_file or regexp in source parameters", err)
+		log.Fatal("must supply either versioned_file, tag_filter or regexp in source parameters", err)
 	}
 
+	versions = api.NewestVersions(versions, checkRequest.Source.MaxVersions)
+
 	versionsJSON, err := json.Marshal(versions)
 	if err != nil {
 		log.Fatal("failed to marshal versions: ", err)
@@ -8,9 +8,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/pivotal-cf/azure-blobstore-resource/api"
 	"github.com/pivotal-cf/azure-blobstore-resource/azure"
@@ -29,25 +29,38 @@ func main() {
 		log.Fatal("failed to decode: ", err)
 	}
 
-	baseURL := storage.DefaultBaseURL
-	if inRequest.Source.BaseURL != "" {
-		baseURL = inRequest.Source.BaseURL
+	config, err := inRequest.Source.AzureConfig()
+	if err != nil {
+		log.Fatal("invalid source configuration: ", err)
 	}
 
-	azureClient := azure.NewClient(
-		baseURL,
-		inRequest.Source.StorageAccountName,
-		inRequest.Source.StorageAccountKey,
-		inRequest.Source.Container,
-	)
+	azureClient, err := azure.NewClient(config)
+	if err != nil {
+		log.Fatal("failed to create azure client: ", err)
+	}
 	in := api.NewIn(azureClient)
 
-	var blobName, versionPath string
-	var snapshot *time.Time
+	versionBy, err := api.ParseVersionBy(inRequest.Source.VersionBy)
+	if err != nil {
+		log.Fatal("invalid source configuration: ", err)
+	}
+
+	var blobName, versionPath, versionID, etag string
+	var snapshot, lastModified *time.Time
 	if inRequest.Source.VersionedFile != "" {
 		blobName = inRequest.Source.VersionedFile
-		snapshot = &inRequest.Version.Snapshot
-	} else if inRequest.Source.Regexp != "" {
+
+		switch versionBy {
+		case api.VersionByVersionID:
+			versionID = inRequest.Version.VersionID
+		case api.VersionByETag:
+			etag = inRequest.Version.ETag
+		case api.VersionByLastModified:
+			lastModified = &inRequest.Version.LastModified
+		default:
+			snapshot = &inRequest.Version.Snapshot
+		}
+	} else if inRequest.Source.Regexp != "" || inRequest.Source.TagFilter != "" {
 		blobName = inRequest.Version.Path
 		versionPath = inRequest.Version.Path
 	}
@@ -63,13 +76,32 @@ func main() {
 	}
 
 	if !inRequest.Params.SkipDownload {
-		err = in.CopyBlobToDestination(
-			destinationDirectory,
-			blobName,
-			snapshot,
-			blockSize,
-			retryTryTimeout,
-		)
+		if versionID != "" {
+			err = in.CopyBlobVersionToDestination(
+				destinationDirectory,
+				blobName,
+				versionID,
+				blockSize,
+				retryTryTimeout,
+			)
+		} else if etag != "" || lastModified != nil {
+			err = in.CopyBlobToDestinationIfUnchanged(
+				destinationDirectory,
+				blobName,
+				etag,
+				inRequest.Version.LastModified,
+				blockSize,
+				retryTryTimeout,
+			)
+		} else {
+			err = in.CopyBlobToDestination(
+				destinationDirectory,
+				blobName,
+				snapshot,
+				blockSize,
+				retryTryTimeout,
+			)
+		}
 		if err != nil {
 			log.Fatal("failed to copy blob: ", err)
 		}
@@ -87,13 +119,20 @@ func main() {
 		log.Fatal("failed to get blob url: ", err)
 	}
 
-	if inRequest.Source.VersionedFile != "" {
+	if snapshot != nil {
 		url, err = api.URLAppendTimeStamp(url, inRequest.Version.Snapshot)
 		if err != nil {
 			log.Fatal("failed to get blob snapshot url: ", err)
 		}
 	}
 
+	if versionID != "" {
+		url, err = api.URLAppendVersionID(url, versionID)
+		if err != nil {
+			log.Fatal("failed to get blob version url: ", err)
+		}
+	}
+
 	err = ioutil.WriteFile(filepath.Join(destinationDirectory, "url"), []byte(url), os.ModePerm)
 	if err != nil {
 		log.Fatal("failed to write blob url to output directory: ", err)
@@ -105,11 +144,28 @@ func main() {
 		log.Fatal("failed to write blob version to output directory: ", err)
 	}
 
+	if inRequest.Source.Regexp != "" && versionPath != "" {
+		matcher, err := regexp.Compile(inRequest.Source.Regexp)
+		if err != nil {
+			log.Fatal("failed to compile source configuration regex: ", err)
+		}
+
+		for group, value := range api.RegexpVersionGroups(matcher, versionPath) {
+			err = ioutil.WriteFile(filepath.Join(destinationDirectory, group), []byte(value), os.ModePerm)
+			if err != nil {
+				log.Fatal("failed to write blob version group to output directory: ", err)
+			}
+		}
+	}
+
 	versionsJSON, err := json.Marshal(api.Response{
 		Version: api.ResponseVersion{
-			Snapshot: snapshot,
-			Path:     versionPath,
-			Version:  inRequest.Version.Version,
+			Snapshot:     snapshot,
+			Path:         versionPath,
+			Version:      inRequest.Version.Version,
+			VersionID:    versionID,
+			ETag:         etag,
+			LastModified: lastModified,
 		},
 		Metadata: []api.ResponseMetadata{
 			{
@@ -10,8 +10,6 @@ import (
 	"regexp"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/storage"
-	"github.com/cppforlife/go-semi-semantic/version"
 	"github.com/pivotal-cf/azure-blobstore-resource/api"
 	"github.com/pivotal-cf/azure-blobstore-resource/azure"
 )
@@ -30,24 +28,27 @@ func main() {
 		log.Fatal("failed to decode: ", err)
 	}
 
-	baseURL := storage.DefaultBaseURL
-	if outRequest.Source.BaseURL != "" {
-		baseURL = outRequest.Source.BaseURL
+	config, err := outRequest.Source.AzureConfig()
+	if err != nil {
+		log.Fatal("invalid source configuration: ", err)
 	}
 
-	azureClient := azure.NewClient(
-		baseURL,
-		outRequest.Source.StorageAccountName,
-		outRequest.Source.StorageAccountKey,
-		outRequest.Source.Container,
-	)
+	azureClient, err := azure.NewClient(config)
+	if err != nil {
+		log.Fatal("failed to create azure client: ", err)
+	}
 	out := api.NewOut(azureClient)
 
+	versionBy, err := api.ParseVersionBy(outRequest.Source.VersionBy)
+	if err != nil {
+		log.Fatal("invalid source configuration: ", err)
+	}
+
 	var blobName string
 	var createSnapshot bool
 	if outRequest.Source.VersionedFile != "" {
 		blobName = outRequest.Source.VersionedFile
-		createSnapshot = true
+		createSnapshot = versionBy == api.VersionBySnapshot
 	} else if outRequest.Source.Regexp != "" {
 		blobPath := filepath.Dir(outRequest.Source.Regexp)
 		blobBaseName := filepath.Base(outRequest.Params.File)
@@ -65,52 +66,77 @@ func main() {
 		retryTryTimeout = time.Duration(*outRequest.Params.Retry.TryTimeout)
 	}
 
-	path, snapshot, err := out.UploadFileToBlobstore(
-		sourceDirectory,
-		outRequest.Params.File,
-		blobName,
-		createSnapshot,
-		blockSize,
-		retryTryTimeout,
-	)
+	var path, versionID, uploadedETag string
+	var snapshot *time.Time
+	var uploadedLastModified time.Time
+	if outRequest.Source.VersionedFile != "" && versionBy == api.VersionByVersionID {
+		path, versionID, err = out.UploadFileToBlobstoreVersion(
+			sourceDirectory,
+			outRequest.Params.File,
+			blobName,
+			blockSize,
+			retryTryTimeout,
+		)
+	} else if createSnapshot {
+		path, snapshot, err = out.UploadFileToBlobstore(
+			sourceDirectory,
+			outRequest.Params.File,
+			blobName,
+			createSnapshot,
+			blockSize,
+			retryTryTimeout,
+		)
+	} else {
+		path, uploadedETag, uploadedLastModified, err = out.UploadFileToBlobstoreETag(
+			sourceDirectory,
+			outRequest.Params.File,
+			blobName,
+			outRequest.Source.VersionedFile != "",
+			blockSize,
+			retryTryTimeout,
+		)
+	}
 	if err != nil {
 		log.Fatal("failed to upload blob: ", err)
 	}
 
-	var ver version.Version
-	if createSnapshot {
+	var ver string
+	var etag string
+	var lastModified *time.Time
+	if outRequest.Source.VersionedFile != "" {
 		path = ""
+
+		switch versionBy {
+		case api.VersionByETag:
+			etag = uploadedETag
+		case api.VersionByLastModified:
+			lastModified = &uploadedLastModified
+		}
 	} else {
+		options, err := outRequest.Source.RegexpOptions()
+		if err != nil {
+			log.Fatal("invalid source configuration: ", err)
+		}
+
 		matcher, err := regexp.Compile(outRequest.Source.Regexp)
 		if err != nil {
 			log.Fatal("failed to compile source configuration regex: ", err)
 		}
 
-		matches := matcher.FindStringSubmatch(path)
-		// No error if `len(matches) < 2` to preserve behaviour that the
-		// resource doesn't error if the regex doesn't find a match in the
-		// uploaded blob path
-		if len(matches) >= 2 {
-			var match string
-			index, found := api.FindSubexpression(matcher.SubexpNames(), "version")
-			if found {
-				match = matches[index]
-			} else {
-				match = matches[1]
-			}
-
-			ver, err = version.NewVersionFromString(match)
-			if err != nil {
-				log.Fatal("failed to convert version from string: ", err)
-			}
+		ver, err = api.UploadedVersion(matcher, path, uploadedLastModified, outRequest.Source.VersionMetadataKey, options)
+		if err != nil {
+			log.Fatal("failed to convert version from string: ", err)
 		}
 	}
 
 	versionsJSON, err := json.Marshal(api.Response{
 		Version: api.ResponseVersion{
-			Snapshot: snapshot,
-			Path:     path,
-			Version:  ver.AsString(),
+			Snapshot:     snapshot,
+			Path:         path,
+			Version:      ver,
+			VersionID:    versionID,
+			ETag:         etag,
+			LastModified: lastModified,
 		},
 	})
 	if err != nil {